@@ -0,0 +1,293 @@
+package sentiment
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Uncertainty is a measure of how unsure a
+// model is of a document's class
+type Uncertainty string
+
+// Constants hold the uncertainty measures.
+// The zero value is Margin.
+const (
+	// Margin is one minus the difference
+	// between the probabilities of the two
+	// most likely classes
+	Margin Uncertainty = "margin"
+
+	// Entropy is the entropy of the class
+	// probabilities, divided by its largest
+	// possible value so it's between 0 and 1
+	Entropy Uncertainty = "entropy"
+)
+
+// UncertaintyOptions configures how
+// uncertain documents are picked. A nil
+// (or zero) UncertaintyOptions picks them
+// by Margin without filtering.
+type UncertaintyOptions struct {
+	// Measure is how uncertainty is scored
+	Measure Uncertainty
+
+	// Diversity, if set, is the highest
+	// estimated similarity (from 0 to 1) two
+	// picked documents can have, so labelers
+	// aren't handed the same comment twice.
+	// Of two documents more alike than this
+	// only the more uncertain is picked.
+	Diversity float64
+}
+
+// Candidate is an unlabeled document a
+// model is unsure about
+type Candidate struct {
+	Path string `json:"path,omitempty"`
+	Text string `json:"text"`
+
+	// Predicted is the class the model
+	// predicted, with the probability of
+	// each class in Probabilities
+	Predicted     Class     `json:"predicted"`
+	Probabilities []float64 `json:"probabilities"`
+
+	// Uncertainty is how unsure the model
+	// is, from 0 to 1
+	Uncertainty float64 `json:"uncertainty"`
+}
+
+// MostUncertain scores every document in the
+// corpus with the model for the language and
+// returns the n the model is least sure of,
+// most uncertain first. The corpus' labels
+// are ignored. See the Model method for more.
+func (m Models) MostUncertain(corpus Corpus, lang Language, n int, opts *UncertaintyOptions) ([]*Candidate, error) {
+	model, ok := m[lang]
+	if !ok {
+		return nil, fmt.Errorf("ERROR: no model for language < %v > to score with", lang)
+	}
+
+	return model.MostUncertain(corpus, n, opts)
+}
+
+// MostUncertain scores every document in the
+// corpus and returns the n the model is least
+// sure of, most uncertain first, which are
+// the ones worth spending a labeling budget
+// on. Only the n best are held in memory, so
+// the corpus can be as big as you like.
+// Documents that can't be read are skipped.
+// opts can be nil to pick by Margin.
+func (m *Model) MostUncertain(corpus Corpus, n int, opts *UncertaintyOptions) ([]*Candidate, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("ERROR: need to pick at least 1 document, not %v", n)
+	}
+
+	measure, diversity := Margin, 0.0
+	if opts != nil {
+		switch opts.Measure {
+		case "":
+		case Margin, Entropy:
+			measure = opts.Measure
+		default:
+			return nil, fmt.Errorf("ERROR: unknown uncertainty measure < %v >", opts.Measure)
+		}
+		diversity = opts.Diversity
+	}
+
+	picked := &candidateHeap{}
+	var seq int
+	err := corpus.Walk(func(doc Document, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		m.mu.RLock()
+		class, probs := m.probabilities(doc.Text)
+		m.mu.RUnlock()
+
+		c := &candidate{
+			Candidate: Candidate{
+				Path:          doc.Path,
+				Text:          doc.Text,
+				Predicted:     Class(class),
+				Probabilities: probs,
+				Uncertainty:   uncertainty(measure, probs),
+			},
+			seq: seq,
+		}
+		seq++
+
+		if picked.Len() == n && !picked.less(picked.items[0], c) {
+			return nil
+		}
+
+		if diversity > 0 {
+			c.signature = minhash(normalizedWords(doc.Text), 3)
+
+			// only the most uncertain of similar
+			// documents is kept
+			var similar []*candidate
+			for _, other := range picked.items {
+				if similarity(&c.signature, &other.signature) >= diversity {
+					if !picked.less(other, c) {
+						return nil
+					}
+					similar = append(similar, other)
+				}
+			}
+			for _, other := range similar {
+				heap.Remove(picked, other.index)
+			}
+		}
+
+		heap.Push(picked, c)
+		if picked.Len() > n {
+			heap.Pop(picked)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := picked.items
+	sort.Slice(items, func(i, j int) bool {
+		return picked.less(items[j], items[i])
+	})
+
+	candidates := make([]*Candidate, len(items))
+	for i, c := range items {
+		candidates[i] = &c.Candidate
+	}
+
+	return candidates, nil
+}
+
+// WriteLabelingQueue writes the candidates as
+// JSON Lines, one per line with an empty
+// "label" field for labelers to fill in with
+// the name of the class ("negative",
+// "positive" or "neutral".) Read it back
+// for training with NewLabelingQueueCorpus;
+// candidates left unlabeled are skipped.
+func WriteLabelingQueue(w io.Writer, candidates []*Candidate) error {
+	encoder := json.NewEncoder(w)
+	for _, c := range candidates {
+		err := encoder.Encode(struct {
+			*Candidate
+			Label *Class `json:"label"`
+		}{
+			Candidate: c,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewLabelingQueueCorpus returns a corpus
+// reading the documents labeled in a queue
+// written by WriteLabelingQueue at path on
+// disk, skipping the ones that haven't been
+// labeled yet
+func NewLabelingQueueCorpus(path string) *JSONLCorpus {
+	return &JSONLCorpus{
+		FS:         os.DirFS(filepath.Dir(path)),
+		Name:       filepath.Base(path),
+		TextField:  "text",
+		LabelField: "label",
+		Labels:     SentimentLabels,
+
+		SkipUnlabeled: true,
+	}
+}
+
+// uncertainty scores how unsure a model
+// with the class probabilities is
+func uncertainty(measure Uncertainty, probs []float64) float64 {
+	if len(probs) < 2 {
+		return 0
+	}
+
+	if measure == Entropy {
+		var h float64
+		for _, p := range probs {
+			if p > 0 {
+				h -= p * math.Log(p)
+			}
+		}
+		return h / math.Log(float64(len(probs)))
+	}
+
+	var first, second float64
+	for _, p := range probs {
+		switch {
+		case p > first:
+			first, second = p, first
+		case p > second:
+			second = p
+		}
+	}
+
+	return 1 - (first - second)
+}
+
+// candidate is a Candidate being ranked
+type candidate struct {
+	Candidate
+
+	// seq is the position of the document
+	// in the corpus, which breaks ties
+	seq       int
+	index     int
+	signature [minhashSize]uint64
+}
+
+// candidateHeap is a min-heap of candidates,
+// with the least uncertain on top
+type candidateHeap struct {
+	items []*candidate
+}
+
+// less returns whether a is less worth
+// labeling than b. Of equally uncertain
+// documents the earlier one is picked.
+func (h *candidateHeap) less(a, b *candidate) bool {
+	if a.Uncertainty != b.Uncertainty {
+		return a.Uncertainty < b.Uncertainty
+	}
+	return a.seq > b.seq
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+
+func (h *candidateHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+
+func (h *candidateHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *candidateHeap) Push(x interface{}) {
+	c := x.(*candidate)
+	c.index = len(h.items)
+	h.items = append(h.items, c)
+}
+
+func (h *candidateHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,161 @@
+package sentiment
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var unlabeled = &TextCorpus{
+	FS: fstest.MapFS{
+		"comments.txt": {Data: []byte(strings.Join([]string{
+			"a wonderful and delightful film",
+			"nothing known here",
+			"an awful and boring story",
+			"Nothing known here!",
+			"a wonderful story",
+		}, "\n"))},
+	},
+	Name: "comments.txt",
+}
+
+func TestMostUncertainShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Priors: []float64{0.5, 0.5}})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	for _, measure := range []Uncertainty{Margin, Entropy} {
+		candidates, err := models.MostUncertain(unlabeled, English, 3, &UncertaintyOptions{Measure: measure})
+		if err != nil {
+			t.Fatalf("Picking uncertain documents by %v should not return an error!\n\t%v\n", measure, err)
+		}
+
+		var paths []string
+		for _, c := range candidates {
+			paths = append(paths, c.Path)
+		}
+		if strings.Join(paths, " ") != "comments.txt:2 comments.txt:4 comments.txt:5" {
+			t.Errorf("Documents without known words should be the most uncertain by %v\n\treturned %v\n", measure, paths)
+		}
+
+		if candidates[0].Uncertainty < 0.999 || candidates[2].Uncertainty >= candidates[1].Uncertainty {
+			t.Errorf("Candidates should be sorted by %v, most uncertain first\n\treturned %v, %v, %v\n", measure, candidates[0].Uncertainty, candidates[1].Uncertainty, candidates[2].Uncertainty)
+		}
+		if candidates[2].Predicted != Positive || len(candidates[2].Probabilities) != 2 {
+			t.Errorf("Candidates should hold the model's prediction\n\treturned %+v\n", candidates[2])
+		}
+	}
+}
+
+func TestMostUncertainShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Priors: []float64{0.5, 0.5}})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	// the near duplicate is dropped in
+	// favor of the next most uncertain
+	candidates, err := models[English].MostUncertain(unlabeled, 2, &UncertaintyOptions{Diversity: 0.5})
+	if err != nil {
+		t.Fatalf("Picking diverse uncertain documents should not return an error!\n\t%v\n", err)
+	}
+
+	if len(candidates) != 2 || candidates[0].Path != "comments.txt:2" || candidates[1].Path != "comments.txt:5" {
+		t.Errorf("Diverse candidates should skip near duplicates\n\treturned %+v\n", candidates)
+	}
+}
+
+func TestLabelingQueueShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, nil)
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	candidates, err := models[English].MostUncertain(unlabeled, 3, nil)
+	if err != nil {
+		t.Fatalf("Picking uncertain documents should not return an error!\n\t%v\n", err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteLabelingQueue(&buf, candidates)
+	if err != nil {
+		t.Fatalf("Writing a labeling queue should not return an error!\n\t%v\n", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"label":null`) {
+		t.Fatalf("Labeling queue should have a line per candidate with an empty label\n\treturned %v\n", buf.String())
+	}
+
+	// a labeler fills in the first two
+	lines[0] = strings.Replace(lines[0], `"label":null`, `"label":"neutral"`, 1)
+	lines[1] = strings.Replace(lines[1], `"label":null`, `"label":"negative"`, 1)
+
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	err = os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatalf("Writing the labeled queue should not return an error!\n\t%v\n", err)
+	}
+
+	// the candidate still unlabeled is
+	// skipped rather than failing
+	docs, err := ReadAll(NewLabelingQueueCorpus(path))
+	if err != nil {
+		t.Fatalf("Reading a partly labeled queue should not return an error!\n\t%v\n", err)
+	}
+
+	if len(docs) != 2 || docs[0].Class != uint8(Neutral) || docs[0].Text != candidates[0].Text || docs[1].Class != uint8(Negative) {
+		t.Errorf("Labeled queue should read back the labeled candidates\n\treturned %+v\n", docs)
+	}
+
+	result, err := TrainModel(make(Models), English, NewLabelingQueueCorpus(path), &TrainOptions{Classes: 3, Strict: true})
+	if err != nil {
+		t.Fatalf("Strictly training off of a partly labeled queue should not return an error!\n\t%v\n", err)
+	}
+	if result.Documents != 2 || len(result.Failed) != 0 {
+		t.Errorf("Training should learn the 2 labeled candidates\n\treturned %+v\n", result)
+	}
+
+	// a label that isn't a class still fails
+	lines[2] = strings.Replace(lines[2], `"label":null`, `"label":"meh"`, 1)
+	err = os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatalf("Writing the labeled queue should not return an error!\n\t%v\n", err)
+	}
+	if _, err := ReadAll(NewLabelingQueueCorpus(path)); err == nil {
+		t.Errorf("Reading a queue with an unknown label should return an error\n")
+	}
+}
+
+func TestMostUncertainShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, nil)
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	if _, err := models.MostUncertain(unlabeled, English, 3, &UncertaintyOptions{Measure: "variance"}); err == nil {
+		t.Errorf("Picking uncertain documents by an unknown measure should return an error\n")
+	}
+	if _, err := models.MostUncertain(unlabeled, English, 0, nil); err == nil {
+		t.Errorf("Picking no uncertain documents should return an error\n")
+	}
+	if _, err := models.MostUncertain(unlabeled, Language("xx"), 3, nil); err == nil {
+		t.Errorf("Picking uncertain documents without a model should return an error\n")
+	}
+}
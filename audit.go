@@ -0,0 +1,496 @@
+package sentiment
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// Split is a named corpus, like the train
+// or test split of a dataset
+type Split struct {
+	Name   string
+	Corpus Corpus
+}
+
+// AuditOptions configures how corpora are
+// audited. A nil (or zero) AuditOptions
+// audits with the defaults.
+type AuditOptions struct {
+	// Threshold is how similar (the Jaccard
+	// similarity of their shingles, from 0 to
+	// 1) two documents have to be to count
+	// as near duplicates. It defaults to 0.8,
+	// and 1 only finds exact duplicates.
+	Threshold float64
+
+	// Shingle is the number of words in each
+	// shingle documents are compared by. It
+	// defaults to 3.
+	Shingle int
+}
+
+// AuditReport is what auditing corpora
+// found
+type AuditReport struct {
+	// Documents is the number of documents
+	// audited
+	Documents int `json:"documents"`
+
+	// Empty is the number of documents with
+	// no words once case and punctuation are
+	// ignored. There's nothing to compare
+	// them by, so they're left out of the
+	// audit (and kept by Clean.)
+	Empty int `json:"empty,omitempty"`
+
+	// Failed holds the documents that
+	// couldn't be read, which were skipped
+	Failed []*DocumentError `json:"failed,omitempty"`
+
+	// Groups holds every set of documents
+	// that are duplicates of each other
+	Groups []*DuplicateGroup `json:"groups"`
+
+	splits []Split
+}
+
+// DuplicateGroup is a set of documents which
+// are exact or near duplicates of each other
+type DuplicateGroup struct {
+	Documents []AuditedDocument `json:"documents"`
+
+	// Exact is whether every document in the
+	// group is the same once case, punctuation
+	// and spacing are ignored
+	Exact bool `json:"exact"`
+
+	// Similarity is the lowest estimated
+	// similarity of a document in the group
+	// to the first one
+	Similarity float64 `json:"similarity"`
+
+	// Conflict is whether the documents
+	// don't all have the same class
+	Conflict bool `json:"conflict"`
+
+	// Leak is whether the documents come
+	// from more than one split
+	Leak bool `json:"leak"`
+}
+
+// AuditedDocument is a document in a
+// DuplicateGroup
+type AuditedDocument struct {
+	Split string `json:"split,omitempty"`
+
+	// Index is the position of the document
+	// in its split's walk
+	Index int    `json:"index"`
+	Path  string `json:"path,omitempty"`
+	Class uint8  `json:"class"`
+}
+
+// minhash signatures are split into bands
+// of rows, and documents which share a band
+// are compared, which finds pairs that are
+// at least about (1/bands)^(1/rows) = 50%
+// similar
+const (
+	minhashBands = 16
+	minhashRows  = 4
+	minhashSize  = minhashBands * minhashRows
+)
+
+// AuditCorpus audits a single corpus for
+// exact and near duplicate documents, and
+// duplicates with conflicting labels. opts
+// can be nil to audit with the defaults.
+func AuditCorpus(corpus Corpus, opts *AuditOptions) (*AuditReport, error) {
+	return Audit([]Split{{Corpus: corpus}}, opts)
+}
+
+// Audit finds exact and near duplicate
+// documents (by normalized hashing and
+// MinHash over word shingles) within and
+// across the splits, flagging duplicates
+// with conflicting labels and duplicates
+// which leak from one split into another.
+// Corpora are walked twice (once here and
+// again by Clean) so they have to walk
+// their documents in the same order every
+// time, which every corpus in this package
+// does. opts can be nil to audit with the
+// defaults.
+func Audit(splits []Split, opts *AuditOptions) (*AuditReport, error) {
+	threshold, shingle := 0.8, 3
+	if opts != nil && opts.Threshold > 0 {
+		threshold = opts.Threshold
+	}
+	if opts != nil && opts.Shingle > 0 {
+		shingle = opts.Shingle
+	}
+
+	report := &AuditReport{
+		splits: splits,
+	}
+
+	var docs []AuditedDocument
+	var exact []uint64
+	var signatures [][minhashSize]uint64
+	for _, split := range splits {
+		var index int
+		err := split.Corpus.Walk(func(doc Document, err error) error {
+			if err != nil {
+				e, ok := err.(*DocumentError)
+				if !ok {
+					e = &DocumentError{Path: doc.Path, Err: err}
+				}
+				report.Failed = append(report.Failed, e)
+				return nil
+			}
+
+			words := normalizedWords(doc.Text)
+			if len(words) == 0 {
+				report.Empty++
+				index++
+				return nil
+			}
+
+			docs = append(docs, AuditedDocument{
+				Split: split.Name,
+				Index: index,
+				Path:  doc.Path,
+				Class: doc.Class,
+			})
+			exact = append(exact, hashString(strings.Join(words, " ")))
+			signatures = append(signatures, minhash(words, shingle))
+
+			index++
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error auditing corpus < %v >!\n\t%w\n", split.Name, err)
+		}
+	}
+	report.Documents = len(docs)
+
+	groups := newUnionFind(len(docs))
+
+	// exact duplicates hash the same, and
+	// only the first of them needs to be
+	// compared to other documents since the
+	// rest are already in its group
+	byHash := make(map[uint64]int)
+	var unique []int
+	for i, h := range exact {
+		if j, ok := byHash[h]; ok {
+			groups.union(i, j)
+			continue
+		}
+		byHash[h] = i
+		unique = append(unique, i)
+	}
+
+	// near duplicates are likely to have the
+	// same minhashes in at least one band
+	if threshold < 1 {
+		for band := 0; band < minhashBands; band++ {
+			buckets := make(map[uint64][]int)
+			for _, i := range unique {
+				sig := &signatures[i]
+				key := hashBand(sig[band*minhashRows : (band+1)*minhashRows])
+				for _, j := range buckets[key] {
+					if groups.find(i) != groups.find(j) && similarity(sig, &signatures[j]) >= threshold {
+						groups.union(i, j)
+					}
+				}
+				buckets[key] = append(buckets[key], i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range docs {
+		root := groups.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.Ints(group)
+		first := group[0]
+		dup := &DuplicateGroup{
+			Exact:      true,
+			Similarity: 1,
+		}
+		for _, i := range group {
+			doc := docs[i]
+			dup.Documents = append(dup.Documents, doc)
+
+			if exact[i] != exact[first] {
+				dup.Exact = false
+			}
+			if s := similarity(&signatures[i], &signatures[first]); s < dup.Similarity {
+				dup.Similarity = s
+			}
+			if doc.Class != docs[first].Class {
+				dup.Conflict = true
+			}
+			if doc.Split != docs[first].Split {
+				dup.Leak = true
+			}
+		}
+
+		report.Groups = append(report.Groups, dup)
+	}
+
+	// in walk order, so reports are
+	// reproducible
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i].Documents[0], report.Groups[j].Documents[0]
+		if a.Split != b.Split {
+			return splitIndex(splits, a.Split) < splitIndex(splits, b.Split)
+		}
+		return a.Index < b.Index
+	})
+
+	return report, nil
+}
+
+// Clean returns the named split without
+// the documents the audit found problems
+// with, ready to be trained on. Every
+// document in a group with conflicting
+// labels or which leaks into another split
+// is dropped, and only the first of any
+// other duplicates is kept.
+func (r *AuditReport) Clean(split string) (Corpus, error) {
+	i := splitIndex(r.splits, split)
+	if i < 0 {
+		return nil, fmt.Errorf("ERROR: no split < %v > was audited", split)
+	}
+
+	drop := make(map[int]bool)
+	for _, group := range r.Groups {
+		kept := false
+		for _, doc := range group.Documents {
+			if doc.Split != split {
+				continue
+			}
+
+			if group.Conflict || group.Leak || kept {
+				drop[doc.Index] = true
+			}
+			kept = true
+		}
+	}
+
+	return &cleanCorpus{
+		corpus: r.splits[i].Corpus,
+		drop:   drop,
+	}, nil
+}
+
+// String returns a summary of the audit
+func (r *AuditReport) String() string {
+	var exact, near, conflicts, leaks, duplicates int
+	for _, group := range r.Groups {
+		if group.Exact {
+			exact++
+		} else {
+			near++
+		}
+		if group.Conflict {
+			conflicts++
+		}
+		if group.Leak {
+			leaks++
+		}
+		duplicates += len(group.Documents) - 1
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "documents\t%v\n", r.Documents)
+	fmt.Fprintf(w, "unreadable\t%v\n", len(r.Failed))
+	fmt.Fprintf(w, "empty\t%v\n", r.Empty)
+	fmt.Fprintf(w, "exact duplicate groups\t%v\n", exact)
+	fmt.Fprintf(w, "near duplicate groups\t%v\n", near)
+	fmt.Fprintf(w, "redundant documents\t%v\n", duplicates)
+	fmt.Fprintf(w, "conflicting labels\t%v\n", conflicts)
+	fmt.Fprintf(w, "leaks across splits\t%v\n", leaks)
+	w.Flush()
+
+	return buf.String()
+}
+
+// cleanCorpus walks a corpus, skipping
+// the documents at the given positions
+type cleanCorpus struct {
+	corpus Corpus
+	drop   map[int]bool
+}
+
+func (c *cleanCorpus) Walk(fn WalkFunc) error {
+	return c.corpus.Walk(c.skip(fn))
+}
+
+// WalkConcurrent reads the underlying corpus
+// concurrently if it can be
+func (c *cleanCorpus) WalkConcurrent(workers int, fn WalkFunc) error {
+	if concurrent, ok := c.corpus.(ConcurrentCorpus); ok {
+		return concurrent.WalkConcurrent(workers, c.skip(fn))
+	}
+
+	return c.Walk(fn)
+}
+
+// skip wraps fn so it isn't called with
+// dropped documents. Documents that couldn't
+// be read aren't counted, same as the audit.
+func (c *cleanCorpus) skip(fn WalkFunc) WalkFunc {
+	var index int
+	return func(doc Document, err error) error {
+		if err != nil {
+			return fn(doc, err)
+		}
+
+		i := index
+		index++
+		if c.drop[i] {
+			return nil
+		}
+
+		return fn(doc, nil)
+	}
+}
+
+// splitIndex returns the position of the
+// named split, or -1
+func splitIndex(splits []Split, name string) int {
+	for i, split := range splits {
+		if split.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// normalizedWords lower cases the text and
+// splits it into words, ignoring punctuation
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// minhash returns the MinHash signature of
+// the text's shingles of n words. Texts
+// shorter than a shingle are one shingle.
+func minhash(words []string, n int) [minhashSize]uint64 {
+	var sig [minhashSize]uint64
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+
+	add := func(shingle []string) {
+		h := hashString(strings.Join(shingle, " "))
+		for i := range sig {
+			if v := mix(h ^ minhashSeeds[i]); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+
+	if len(words) <= n {
+		add(words)
+		return sig
+	}
+	for i := 0; i+n <= len(words); i++ {
+		add(words[i : i+n])
+	}
+
+	return sig
+}
+
+// similarity estimates the Jaccard similarity
+// of two documents from their signatures
+func similarity(a, b *[minhashSize]uint64) float64 {
+	var same int
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+
+	return float64(same) / minhashSize
+}
+
+// minhashSeeds are the seeds of each of the
+// hash functions in a signature, fixed so
+// audits are reproducible
+var minhashSeeds = func() [minhashSize]uint64 {
+	var seeds [minhashSize]uint64
+	var x uint64
+	for i := range seeds {
+		x += 0x9e3779b97f4a7c15
+		seeds[i] = mix(x)
+	}
+	return seeds
+}()
+
+// mix is the splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func hashBand(band []uint64) uint64 {
+	var h uint64 = 14695981039346656037
+	for _, v := range band {
+		h = mix(h ^ v)
+	}
+	return h
+}
+
+// unionFind groups documents into sets
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+func (u unionFind) union(i, j int) {
+	u[u.find(i)] = u.find(j)
+}
@@ -0,0 +1,144 @@
+package sentiment
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	auditTrain = Documents{
+		{Path: "a", Text: "What a wonderful film, I loved every minute of it", Class: 1},
+		{Path: "b", Text: "what a WONDERFUL film!! i loved every minute of it.", Class: 1},
+		{Path: "c", Text: "The plot was dull and the acting was wooden, a complete waste of two hours of my life", Class: 0},
+		{Path: "d", Text: "Great soundtrack", Class: 1},
+		{Path: "e", Text: "great soundtrack", Class: 0},
+		{Path: "f", Text: "A heartfelt story about a family that I will not forget", Class: 1},
+	}
+	auditTest = Documents{
+		{Path: "x", Text: "The plot was dull and the acting was wooden, a complete waste of two hours of my evening", Class: 0},
+		{Path: "y", Text: "Not my kind of movie at all", Class: 0},
+	}
+)
+
+func TestAuditShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	report, err := Audit([]Split{{"train", auditTrain}, {"test", auditTest}}, nil)
+	if err != nil {
+		t.Fatalf("Auditing corpora should not return an error!\n\t%v\n", err)
+	}
+
+	if report.Documents != 8 || len(report.Groups) != 3 {
+		t.Fatalf("Audit should find 3 groups of duplicates in 8 documents\n\treturned %v groups in %v\n%v\n", len(report.Groups), report.Documents, report)
+	}
+
+	exact, leak, conflict := report.Groups[0], report.Groups[1], report.Groups[2]
+	if !exact.Exact || exact.Conflict || exact.Leak || len(exact.Documents) != 2 {
+		t.Errorf("Documents differing in case and punctuation should be exact duplicates\n\treturned %+v\n", exact)
+	}
+	if leak.Exact || !leak.Leak || leak.Similarity < 0.8 || leak.Documents[1].Split != "test" {
+		t.Errorf("Documents differing by a word should be near duplicates leaking into the test split\n\treturned %+v\n", leak)
+	}
+	if !conflict.Conflict || conflict.Leak {
+		t.Errorf("Duplicates with different classes should conflict\n\treturned %+v\n", conflict)
+	}
+
+	clean, err := report.Clean("train")
+	if err != nil {
+		t.Fatalf("Cleaning an audited split should not return an error!\n\t%v\n", err)
+	}
+	docs, err := ReadAll(clean)
+	if err != nil {
+		t.Fatalf("Reading a cleaned corpus should not return an error!\n\t%v\n", err)
+	}
+
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"a", "f"}) {
+		t.Errorf("Cleaned split should drop duplicates, conflicts and leaks\n\treturned %v\n", paths)
+	}
+}
+
+func TestAuditShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	// only exact duplicates
+	report, err := AuditCorpus(append(auditTrain, auditTest...), &AuditOptions{Threshold: 1})
+	if err != nil {
+		t.Fatalf("Auditing a corpus should not return an error!\n\t%v\n", err)
+	}
+
+	for _, group := range report.Groups {
+		if !group.Exact {
+			t.Errorf("Audit with a threshold of 1 should only find exact duplicates\n\treturned %+v\n", group)
+		}
+	}
+	if len(report.Groups) != 2 {
+		t.Errorf("Audit should find 2 groups of exact duplicates\n\treturned %v\n", len(report.Groups))
+	}
+}
+
+func TestAuditShouldPass3(t *testing.T) {
+	t.Parallel()
+
+	// lots of copies of one document, a near
+	// duplicate of them, and documents with
+	// no words at all
+	var corpus Documents
+	corpus = append(corpus, Document{Path: "empty", Text: ""})
+	for i := 0; i < 5000; i++ {
+		corpus = append(corpus, Document{Path: "copy", Text: "Buy now, limited offer on cheap watches and bags from the best brands in town", Class: 1})
+	}
+	corpus = append(corpus,
+		Document{Path: "punctuation", Text: "!!! ... ???"},
+		Document{Path: "near", Text: "Buy now, limited offer on cheap watches and bags from the best brands in London", Class: 1},
+		Document{Path: "other", Text: "A quiet film about growing old", Class: 1},
+	)
+
+	report, err := AuditCorpus(corpus, nil)
+	if err != nil {
+		t.Fatalf("Auditing a corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if report.Documents != 5002 || report.Empty != 2 {
+		t.Errorf("Audit should leave out the 2 documents without words\n\treturned %v audited, %v empty\n", report.Documents, report.Empty)
+	}
+	if len(report.Groups) != 1 || len(report.Groups[0].Documents) != 5001 || report.Groups[0].Exact {
+		t.Fatalf("Audit should find one group of the copies and their near duplicate\n\treturned %v\n", report)
+	}
+	if last := report.Groups[0].Documents[5000]; last.Path != "near" || last.Index != 5002 {
+		t.Errorf("Near duplicate should be in the group at its walk index\n\treturned %+v\n", last)
+	}
+
+	clean, err := report.Clean("")
+	if err != nil {
+		t.Fatalf("Cleaning an audited corpus should not return an error!\n\t%v\n", err)
+	}
+	docs, err := ReadAll(clean)
+	if err != nil {
+		t.Fatalf("Reading a cleaned corpus should not return an error!\n\t%v\n", err)
+	}
+
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"empty", "copy", "punctuation", "other"}) {
+		t.Errorf("Cleaned corpus should keep the first copy and the empty documents\n\treturned %v\n", paths)
+	}
+}
+
+func TestAuditShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	report, err := AuditCorpus(auditTrain, nil)
+	if err != nil {
+		t.Fatalf("Auditing a corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if _, err := report.Clean("missing"); err == nil {
+		t.Errorf("Cleaning a split that wasn't audited should return an error\n")
+	}
+}
@@ -0,0 +1,239 @@
+package sentiment
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+
+	"github.com/cdipaolo/goml/text"
+)
+
+// Balance is a strategy for training on a
+// corpus whose classes aren't the same size,
+// so the model doesn't just learn how
+// skewed the labels are
+type Balance string
+
+// Constants hold the balancing strategies.
+// The zero value learns every document once.
+const (
+	// Undersample learns only as many
+	// documents of each class as the
+	// smallest class has, evenly spaced
+	// through the corpus
+	Undersample Balance = "undersample"
+
+	// Oversample learns documents of the
+	// smaller classes more than once, until
+	// every class is as big as the largest
+	Oversample Balance = "oversample"
+
+	// Reweight learns every document once
+	// and then scales each class's counts as
+	// if every class had the same number of
+	// documents
+	Reweight Balance = "reweight"
+)
+
+// validateBalance checks the options' balancing
+// strategy and priors against the number
+// of classes the model is trained with
+func (o *TrainOptions) validateBalance(classes int) error {
+	if o == nil {
+		return nil
+	}
+
+	switch o.Balance {
+	case "", Undersample, Oversample, Reweight:
+	default:
+		return fmt.Errorf("unknown balancing strategy < %v >", o.Balance)
+	}
+
+	if o.Priors == nil {
+		return nil
+	}
+	if len(o.Priors) != classes {
+		return fmt.Errorf("need %v class priors, not %v", classes, len(o.Priors))
+	}
+
+	var sum float64
+	for _, p := range o.Priors {
+		if p < 0 || math.IsNaN(p) || math.IsInf(p, 0) {
+			return fmt.Errorf("class priors must be positive, not %v", o.Priors)
+		}
+		sum += p
+	}
+	if sum == 0 {
+		return fmt.Errorf("class priors can't all be zero")
+	}
+
+	return nil
+}
+
+// priors returns the options' class
+// priors normalized to sum to 1, or nil
+// if there aren't any
+func (o *TrainOptions) priors() []float64 {
+	if o == nil || o.Priors == nil {
+		return nil
+	}
+
+	var sum float64
+	for _, p := range o.Priors {
+		sum += p
+	}
+
+	priors := make([]float64, len(o.Priors))
+	for i, p := range o.Priors {
+		priors[i] = p / sum
+	}
+
+	return priors
+}
+
+// resamples returns whether the options
+// learn documents more or less than once
+func (o *TrainOptions) resamples() bool {
+	return o != nil && (o.Balance == Undersample || o.Balance == Oversample)
+}
+
+// countClasses walks the corpus, counting
+// the documents of each class. Files in
+// directory corpora are only read up to
+// their first rune that isn't a space, to
+// leave out the blank ones walking them
+// skips. Documents that can't be read are
+// left for training to report.
+func countClasses(ctx context.Context, corpus Corpus, classes int, label func(Document) (uint8, bool)) ([]int, error) {
+	var fsys fs.FS
+	if c, ok := corpus.(*DirCorpus); ok {
+		fsys = c.FS
+		corpus = dirListing{c}
+	}
+
+	counts := make([]int, classes)
+	err := corpus.Walk(func(doc Document, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		if fsys != nil {
+			blank, err := blankFile(fsys, doc.Path)
+			if err != nil || blank {
+				return nil
+			}
+		}
+
+		class, ok := label(doc)
+		if ok && int(class) < classes {
+			counts[class]++
+		}
+		return nil
+	})
+
+	return counts, err
+}
+
+// resampler returns the number of times to
+// learn each document, given the number of
+// documents of each class. The documents
+// kept (or repeated) are spread evenly over
+// each class, so training is reproducible.
+// The returned function must be called for
+// every document in the order they're
+// walked.
+func resampler(balance Balance, counts []int) func(class uint8) int {
+	var target int
+	for _, n := range counts {
+		switch {
+		case n == 0:
+		case target == 0:
+			target = n
+		case balance == Undersample && n < target:
+			target = n
+		case balance == Oversample && n > target:
+			target = n
+		}
+	}
+
+	seen := make([]int, len(counts))
+	return func(class uint8) int {
+		n, i := counts[class], seen[class]
+		seen[class]++
+		if i >= n {
+			// the corpus changed since
+			// it was counted
+			return 1
+		}
+
+		return (i+1)*target/n - i*target/n
+	}
+}
+
+// reweight scales the counts of each class
+// in the freshly trained model as if every
+// class had the same number of documents
+func reweight(b *text.NaiveBayes) error {
+	var total, present float64
+	for _, n := range b.Count {
+		if n > 0 {
+			total += float64(n)
+			present++
+		}
+	}
+	if present == 0 {
+		return nil
+	}
+
+	weights := make([]float64, len(b.Count))
+	for i, n := range b.Count {
+		if n > 0 {
+			weights[i] = total / (present * float64(n))
+		}
+	}
+
+	words, err := vocabulary(b)
+	if err != nil {
+		return err
+	}
+
+	for _, word := range words {
+		w, _ := b.Words.Get(word)
+		w.Seen = 0
+		for i := range w.Count {
+			w.Count[i] = scaleCount(w.Count[i], weights[i])
+			w.Seen += w.Count[i]
+		}
+		b.Words.Set(word, w)
+	}
+
+	b.DocumentCount = 0
+	for i := range b.Count {
+		b.Count[i] = scaleCount(b.Count[i], weights[i])
+		b.DocumentCount += b.Count[i]
+	}
+	for i := range b.Probabilities {
+		b.Probabilities[i] = float64(b.Count[i]) / float64(b.DocumentCount)
+	}
+
+	return nil
+}
+
+// scaleCount scales a count by the weight,
+// never rounding a nonzero count down to
+// zero
+func scaleCount(n uint64, weight float64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	scaled := uint64(math.Round(float64(n) * weight))
+	if scaled == 0 {
+		return 1
+	}
+
+	return scaled
+}
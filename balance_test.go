@@ -0,0 +1,138 @@
+package sentiment
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// skewed is a corpus that's 75% positive
+var skewed = Documents{
+	{Text: "a wonderful film", Class: 1},
+	{Text: "a delightful story", Class: 1},
+	{Text: "lovely music", Class: 1},
+	{Text: "a happy ending", Class: 1},
+	{Text: "great acting", Class: 1},
+	{Text: "a charming cast", Class: 1},
+	{Text: "an awful film", Class: 0},
+	{Text: "a boring story", Class: 0},
+}
+
+func TestBalanceShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	for balance, expected := range map[Balance][]int{
+		"":          {2, 6},
+		Undersample: {2, 2},
+		Oversample:  {6, 6},
+		Reweight:    {2, 6},
+	} {
+		models := make(Models)
+		result, err := TrainModel(models, English, skewed, &TrainOptions{Balance: balance})
+		if err != nil {
+			t.Fatalf("Training with balance < %v > should not return an error!\n\t%v\n", balance, err)
+		}
+
+		if !reflect.DeepEqual(result.Classes, expected) {
+			t.Errorf("Training with balance < %v > should learn %v documents per class\n\treturned %v\n", balance, expected, result.Classes)
+		}
+
+		m := models[English]
+		if m.Balance != balance {
+			t.Errorf("Model should record its balance < %v >\n\treturned %v\n", balance, m.Balance)
+		}
+		if balance != "" && m.Probabilities[0] != 0.5 {
+			t.Errorf("Balanced model (%v) should have even priors\n\treturned %v\n", balance, m.Probabilities)
+		}
+	}
+}
+
+func TestBalanceShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	// the empty negative file in the fixture
+	// shouldn't count towards its class
+	models := make(Models)
+	result, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, &TrainOptions{Balance: Undersample})
+	if err != nil {
+		t.Fatalf("Undersampling a directory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if !reflect.DeepEqual(result.Classes, []int{3, 3}) {
+		t.Errorf("Undersampling a balanced corpus should learn every document\n\treturned %v\n", result.Classes)
+	}
+
+	// nor should a file of only whitespace,
+	// which walking the corpus skips as well
+	fsys := fstest.MapFS{
+		"pos/0.txt": {Data: []byte("a wonderful film")},
+		"pos/1.txt": {Data: []byte("a delightful story")},
+		"pos/2.txt": {Data: []byte("lovely music")},
+		"neg/0.txt": {Data: []byte("an awful film")},
+		"neg/1.txt": {Data: []byte("a boring story")},
+		"neg/2.txt": {Data: []byte(" \r\n\t\u00a0\n")},
+	}
+	result, err = TrainEnglishModelFS(make(Models), fsys, IMDBClassDirs, &TrainOptions{Balance: Oversample})
+	if err != nil {
+		t.Fatalf("Oversampling a directory corpus should not return an error!\n\t%v\n", err)
+	}
+	if !reflect.DeepEqual(result.Classes, []int{3, 3}) {
+		t.Errorf("Oversampling should make up for the blank file\n\treturned %v\n", result.Classes)
+	}
+}
+
+func TestPriorsShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Priors: []float64{1, 3}})
+	if err != nil {
+		t.Fatalf("Training with priors should not return an error!\n\t%v\n", err)
+	}
+
+	m := models[English]
+	if !reflect.DeepEqual(m.Priors, []float64{0.25, 0.75}) || math.Abs(m.Probabilities[0]-0.25) > 1e-9 {
+		t.Errorf("Model should use and record the normalized priors\n\treturned %v (recorded %v)\n", m.Probabilities, m.Priors)
+	}
+
+	err = models.Learn("a dreadful film", 0, English)
+	if err != nil {
+		t.Fatalf("Learning should not return an error!\n\t%v\n", err)
+	}
+	if math.Abs(m.Probabilities[0]-0.25) > 1e-9 {
+		t.Errorf("Learning should keep the model's priors\n\treturned %v\n", m.Probabilities)
+	}
+}
+
+func TestPriorsShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []*TrainOptions{
+		{Priors: []float64{1, 2, 3}},
+		{Priors: []float64{0, 0}},
+		{Priors: []float64{-1, 2}},
+		{Balance: "sideways"},
+	} {
+		_, err := TrainModel(make(Models), English, skewed, opts)
+		if err == nil {
+			t.Errorf("Training with %+v should return an error\n", opts)
+		}
+	}
+}
+
+func TestResamplerShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	copies := resampler(Undersample, []int{2, 6})
+
+	var kept []int
+	for i := 0; i < 6; i++ {
+		kept = append(kept, copies(1))
+	}
+
+	expected := []int{0, 0, 1, 0, 0, 1}
+	if !reflect.DeepEqual(kept, expected) {
+		t.Errorf("Undersampling should keep evenly spaced documents\n\texpected %v\n\treturned %v\n", expected, kept)
+	}
+}
@@ -0,0 +1,659 @@
+package sentiment
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cdipaolo/goml/base"
+	"github.com/cdipaolo/goml/text"
+)
+
+// BinaryVersion is the version of the binary
+// model format MarshalBinary writes, and the
+// newest one RestoreModels can read. Version
+// 2 added manifests, and version 3 records
+// whether the vocabulary was pruned in
+// place of the pruned vocabulary itself.
+const BinaryVersion = 3
+
+// binaryMagic starts every binary model,
+// which can't be mistaken for JSON
+var binaryMagic = []byte("\x00SNTMDL\n")
+
+// The binary model format is
+//
+//	magic    "\x00SNTMDL\n"
+//	version  uvarint
+//	models   uvarint, then that many
+//	         language sections, sorted
+//
+// and a language section is
+//
+//	language    string
+//	length      uvarint, the number of bytes
+//	            in the rest of the section
+//	manifest    string, the JSON encoded
+//	            Manifest (since version 2)
+//	threshold   float64 (NeutralThreshold)
+//	balance     string
+//	priors      floats
+//	variant     string
+//	smoothing   float64
+//	tokens      uvarints (ClassTokens)
+//	vocabulary  strings (before version 3)
+//	pruned      byte (1 if the vocabulary
+//	            was pruned, since version 3)
+//	classifier  table
+//	ratings     byte (1 if there's a star
+//	            rating model), then its table
+//
+// where a word count table is
+//
+//	classes        uvarint
+//	count          classes uvarints
+//	probabilities  classes float64s
+//	documents      uvarint
+//	dictionary     uvarint
+//	words          uvarint, then that many
+//	               words in sorted order, each
+//	               the length of the prefix it
+//	               shares with the last word
+//	               (uvarint), the rest of the
+//	               word (string), then Seen and
+//	               Count (classes uvarints)
+//
+// Strings are their length (uvarint) then
+// their bytes, float64s are 8 little endian
+// bytes, and floats/uvarints/strings are a
+// count (uvarint) then that many values.
+
+// MarshalBinary encodes the models in the
+// compact, versioned binary format, which is
+// much smaller and faster to restore than
+// JSON. RestoreModels reads either.
+func (m Models) MarshalBinary() ([]byte, error) {
+	langs := make([]string, 0, len(m))
+	for lang := range m {
+		langs = append(langs, string(lang))
+	}
+	sort.Strings(langs)
+
+	w := &binaryWriter{}
+	w.Write(binaryMagic)
+	w.uvarint(BinaryVersion)
+	w.uvarint(uint64(len(langs)))
+
+	for _, lang := range langs {
+		model := m[Language(lang)]
+		if model == nil || model.NaiveBayes == nil {
+			return nil, fmt.Errorf("ERROR: model for language < %v > is empty", lang)
+		}
+
+		section, err := model.marshalSection(Language(lang))
+		if err != nil {
+			return nil, fmt.Errorf("Error encoding model for language < %v >!\n\t%w\n", lang, err)
+		}
+
+		w.string(lang)
+		w.uvarint(uint64(len(section)))
+		w.Write(section)
+	}
+
+	return w.Bytes(), nil
+}
+
+// PersistToFileBinary is the same as
+// PersistToFile, but persists the models in
+// the binary format
+func PersistToFileBinary(m Models, path string) error {
+	return m.Persist(path, &PersistOptions{
+		EncodeOptions: EncodeOptions{Format: FormatBinary},
+	})
+}
+
+// isBinary returns whether the data is a
+// binary model
+func isBinary(data []byte) bool {
+	return bytes.HasPrefix(data, binaryMagic)
+}
+
+// unmarshalBinary decodes models in the
+// binary format, leaving RestoreModels to
+// set them up
+func unmarshalBinary(data []byte) (Models, error) {
+	r := &binaryReader{data: data[len(binaryMagic):]}
+
+	version := r.uvarint()
+	if r.err == nil && (version == 0 || version > BinaryVersion) {
+		return nil, fmt.Errorf("ERROR: binary model format version %v isn't supported, this package reads up to version %v", version, BinaryVersion)
+	}
+
+	models := make(Models)
+	n := r.count()
+	for i := 0; i < n && r.err == nil; i++ {
+		lang := Language(r.string())
+		length := r.count()
+		section := &binaryReader{data: r.bytes(length)}
+		if r.err != nil {
+			break
+		}
+
+		var manifest []byte
+		if version >= 2 {
+			manifest = section.bytes(section.count())
+		}
+
+		model, err := unmarshalSection(section, version)
+		if err == nil && len(manifest) != 0 {
+			err = json.Unmarshal(manifest, &model.Manifest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding model for language < %v >!\n\t%w\n", lang, err)
+		}
+		models[lang] = model
+	}
+	if r.err == nil && len(r.data) != 0 {
+		r.fail("%v unexpected bytes after the last model", len(r.data))
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("Error decoding binary models!\n\t%w\n", r.err)
+	}
+
+	return models, nil
+}
+
+// readBinaryManifests reads the manifests
+// of binary models, skipping past the rest
+// of each section without decoding it
+func readBinaryManifests(br *bufio.Reader) (map[Language]*Manifest, error) {
+	r := &binaryStream{r: br}
+	r.skip(uint64(len(binaryMagic)))
+
+	version := r.uvarint()
+	if r.err == nil && (version == 0 || version > BinaryVersion) {
+		return nil, fmt.Errorf("binary model format version %v isn't supported, this package reads up to version %v", version, BinaryVersion)
+	}
+
+	manifests := make(map[Language]*Manifest)
+	n := r.uvarint()
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		lang := Language(r.string())
+		length := r.uvarint()
+		start := r.read
+
+		var manifest *Manifest
+		if version >= 2 {
+			raw := r.string()
+			if r.err == nil && len(raw) != 0 {
+				err := json.Unmarshal([]byte(raw), &manifest)
+				if err != nil {
+					return nil, fmt.Errorf("model for language < %v >: %w", lang, err)
+				}
+			}
+		}
+		if r.err == nil && r.read-start > length {
+			r.fail("manifest for language < %v > is past the end of its section", lang)
+		}
+
+		r.skip(length - (r.read - start))
+		manifests[lang] = manifest
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return manifests, nil
+}
+
+// marshalSection encodes a model's
+// language section, past its length
+func (m *Model) marshalSection(lang Language) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	body, err := m.marshalBody()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := json.Marshal(m.manifest(lang, FormatBinary, contentHash(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	w := &binaryWriter{}
+	w.string(string(manifest))
+	w.Write(body)
+
+	return w.Bytes(), nil
+}
+
+// marshalBody encodes the model's language
+// section past its manifest, which is what
+// its hash is of. The caller must hold the
+// model's lock.
+func (m *Model) marshalBody() ([]byte, error) {
+	w := &binaryWriter{}
+	w.float(m.NeutralThreshold)
+	w.string(string(m.Balance))
+	w.floats(m.Priors)
+	w.string(string(m.Variant))
+	w.float(m.Smoothing)
+	w.uvarints(m.ClassTokens)
+
+	if m.Pruned {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+
+	err := w.table(m.NaiveBayes)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Ratings == nil {
+		w.WriteByte(0)
+		return w.Bytes(), nil
+	}
+
+	w.WriteByte(1)
+	err = w.table(m.Ratings)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+// unmarshalSection decodes a model's
+// language section of the format version,
+// past its manifest
+func unmarshalSection(r *binaryReader, version uint64) (*Model, error) {
+	m := &Model{}
+	m.NeutralThreshold = r.float()
+	m.Balance = Balance(r.string())
+	m.Priors = r.floats()
+	m.Variant = Variant(r.string())
+	m.Smoothing = r.float()
+	m.ClassTokens = r.uvarints()
+
+	if version >= 3 {
+		m.Pruned = r.byte() == 1
+	} else {
+		// older models listed the words
+		// they were pruned down to
+		n := r.count()
+		for i := 0; i < n; i++ {
+			r.string()
+		}
+		m.Pruned = n > 0
+	}
+
+	m.NaiveBayes = r.table()
+	if r.byte() == 1 {
+		m.Ratings = r.table()
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(m.Priors) != 0 && len(m.Priors) != len(m.Count) {
+		return nil, fmt.Errorf("%v priors for %v classes", len(m.Priors), len(m.Count))
+	}
+	if len(r.data) != 0 {
+		return nil, fmt.Errorf("%v unexpected bytes at the end of the section", len(r.data))
+	}
+	err := m.restoreVocabulary()
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// binaryWriter writes the binary format
+type binaryWriter struct {
+	bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (w *binaryWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.Write(w.scratch[:n])
+}
+
+func (w *binaryWriter) float(f float64) {
+	binary.LittleEndian.PutUint64(w.scratch[:8], math.Float64bits(f))
+	w.Write(w.scratch[:8])
+}
+
+func (w *binaryWriter) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (w *binaryWriter) floats(fs []float64) {
+	w.uvarint(uint64(len(fs)))
+	for _, f := range fs {
+		w.float(f)
+	}
+}
+
+func (w *binaryWriter) uvarints(vs []uint64) {
+	w.uvarint(uint64(len(vs)))
+	for _, v := range vs {
+		w.uvarint(v)
+	}
+}
+
+// table writes a classifier's word
+// count table
+func (w *binaryWriter) table(b *text.NaiveBayes) error {
+	raw, err := b.Words.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	var words map[string]text.Word
+	err = json.Unmarshal(raw, &words)
+	if err != nil {
+		return err
+	}
+
+	list := make([]string, 0, len(words))
+	for word := range words {
+		list = append(list, word)
+	}
+	sort.Strings(list)
+
+	classes := len(b.Count)
+	w.uvarint(uint64(classes))
+	for _, count := range b.Count {
+		w.uvarint(count)
+	}
+	for i := 0; i < classes; i++ {
+		var p float64
+		if i < len(b.Probabilities) {
+			p = b.Probabilities[i]
+		}
+		w.float(p)
+	}
+	w.uvarint(b.DocumentCount)
+	w.uvarint(b.DictCount)
+
+	w.uvarint(uint64(len(list)))
+	var last string
+	for _, word := range list {
+		shared := 0
+		for shared < len(word) && shared < len(last) && word[shared] == last[shared] {
+			shared++
+		}
+		w.uvarint(uint64(shared))
+		w.string(word[shared:])
+		last = word
+
+		count := words[word].Count
+		if len(count) != classes {
+			return fmt.Errorf("word < %v > has counts for %v classes, not %v", word, len(count), classes)
+		}
+		w.uvarint(words[word].Seen)
+		for _, c := range count {
+			w.uvarint(c)
+		}
+	}
+
+	return nil
+}
+
+// binaryStream reads the binary format from
+// a stream, counting the bytes it's read.
+// Like binaryReader it keeps the first error
+// it runs into in err.
+type binaryStream struct {
+	r    *bufio.Reader
+	read uint64
+	err  error
+}
+
+func (s *binaryStream) fail(format string, v ...interface{}) {
+	if s.err == nil {
+		s.err = fmt.Errorf(format, v...)
+	}
+}
+
+// ReadByte lets binary.ReadUvarint read
+// from the stream
+func (s *binaryStream) ReadByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	s.read++
+
+	return b, nil
+}
+
+func (s *binaryStream) uvarint() uint64 {
+	if s.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(s)
+	if err != nil {
+		s.fail("malformed or truncated number")
+		return 0
+	}
+
+	return v
+}
+
+// string reads a string, reading as much
+// of it as there is rather than trusting
+// its length so corrupt data can't
+// allocate a huge buffer
+func (s *binaryStream) string() string {
+	n := s.uvarint()
+	if s.err == nil && n > math.MaxInt64 {
+		s.fail("string of %v bytes is too long", n)
+	}
+	if s.err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	copied, err := io.Copy(&b, io.LimitReader(s.r, int64(n)))
+	s.read += uint64(copied)
+	if err != nil || uint64(copied) != n {
+		s.fail("truncated data")
+		return ""
+	}
+
+	return b.String()
+}
+
+func (s *binaryStream) skip(n uint64) {
+	for n > 0 && s.err == nil {
+		chunk := n
+		if chunk > math.MaxInt32 {
+			chunk = math.MaxInt32
+		}
+
+		skipped, err := s.r.Discard(int(chunk))
+		s.read += uint64(skipped)
+		n -= uint64(skipped)
+		if err != nil {
+			s.fail("truncated data")
+		}
+	}
+}
+
+// binaryReader reads the binary format. The
+// first error it runs into is kept in err,
+// after which everything it reads is zero.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) fail(format string, v ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, v...)
+	}
+	r.data = nil
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail("malformed or truncated number")
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return v
+}
+
+// count reads the number of values that
+// follow, which can't be more than the
+// bytes left (every value takes at least
+// one) so corrupt data can't allocate
+// huge slices
+func (r *binaryReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail("count %v is past the end of the data", n)
+		return 0
+	}
+
+	return int(n)
+}
+
+func (r *binaryReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data) {
+		r.fail("truncated data")
+		return nil
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
+
+func (r *binaryReader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+
+	return b[0]
+}
+
+func (r *binaryReader) float() float64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func (r *binaryReader) string() string {
+	return string(r.bytes(r.count()))
+}
+
+func (r *binaryReader) floats() []float64 {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+
+	fs := make([]float64, n)
+	for i := range fs {
+		fs[i] = r.float()
+	}
+
+	return fs
+}
+
+func (r *binaryReader) uvarints() []uint64 {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = r.uvarint()
+	}
+
+	return vs
+}
+
+// table reads a classifier's word
+// count table
+func (r *binaryReader) table() *text.NaiveBayes {
+	classes := r.count()
+	if r.err == nil && (classes < 1 || classes > math.MaxUint8) {
+		r.fail("a classifier can't have %v classes", classes)
+	}
+	if r.err != nil {
+		return nil
+	}
+
+	b := text.NewNaiveBayes(nil, uint8(classes), base.OnlyWords)
+	for i := range b.Count {
+		b.Count[i] = r.uvarint()
+	}
+	for i := range b.Probabilities {
+		b.Probabilities[i] = r.float()
+	}
+	b.DocumentCount = r.uvarint()
+	b.DictCount = r.uvarint()
+
+	var last string
+	n := r.count()
+	for i := 0; i < n && r.err == nil; i++ {
+		shared := int(r.uvarint())
+		if shared > len(last) {
+			r.fail("word %v shares more than the last word", i)
+			break
+		}
+
+		var word strings.Builder
+		word.WriteString(last[:shared])
+		word.WriteString(r.string())
+		last = word.String()
+		if !utf8.ValidString(last) {
+			r.fail("word %v isn't valid UTF-8", i)
+			break
+		}
+
+		w := text.Word{
+			Seen:  r.uvarint(),
+			Count: make([]uint64, classes),
+		}
+		for c := range w.Count {
+			w.Count[c] = r.uvarint()
+		}
+		b.Words.Set(last, w)
+	}
+
+	return b
+}
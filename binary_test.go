@@ -0,0 +1,172 @@
+package sentiment
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// binaryModels returns models using every
+// field the binary format stores
+func binaryModels(t *testing.T) Models {
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, &TrainOptions{
+		MaxVocabulary: 50,
+		Priors:        []float64{0.4, 0.6},
+		Variant:       Complement,
+		Smoothing:     0.5,
+	})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+	_, err = models[English].TrainRatings(&DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs}, nil)
+	if err != nil {
+		t.Fatalf("Training star ratings should not return an error!\n\t%v\n", err)
+	}
+	models[English].SetNeutralThreshold(0.7)
+
+	_, err = TrainModel(models, Spanish, skewed, &TrainOptions{Balance: Oversample, Variant: Bernoulli})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	return models
+}
+
+func TestBinaryShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+
+	data, err := models.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Encoding binary models should not return an error!\n\t%v\n", err)
+	}
+
+	restored, err := RestoreModels(data)
+	if err != nil {
+		t.Fatalf("Restoring binary models should not return an error!\n\t%v\n", err)
+	}
+
+	// converting to and from JSON is lossless
+	expected, err := json.Marshal(models)
+	if err != nil {
+		t.Fatalf("Marshalling models to JSON should not return an error!\n\t%v\n", err)
+	}
+	returned, err := json.Marshal(restored)
+	if err != nil {
+		t.Fatalf("Marshalling restored models to JSON should not return an error!\n\t%v\n", err)
+	}
+	if !bytes.Equal(expected, returned) {
+		t.Errorf("Binary models should restore to the same JSON\n\texpected %v bytes\n\treturned %v bytes\n", len(expected), len(returned))
+	}
+
+	fromJSON, err := RestoreModels(expected)
+	if err != nil {
+		t.Fatalf("Restoring JSON models should not return an error!\n\t%v\n", err)
+	}
+	again, err := fromJSON.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Encoding restored models should not return an error!\n\t%v\n", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("Binary encoding should be the same after a round trip through JSON\n")
+	}
+
+	if len(data) >= len(expected) {
+		t.Errorf("Binary models should be smaller than JSON\n\treturned %v bytes, JSON is %v\n", len(data), len(expected))
+	}
+
+	for _, sentence := range []string{"I loved this movie", "an awful and boring story", "meh"} {
+		a := models.SentimentAnalysis(sentence, English)
+		b := restored.SentimentAnalysis(sentence, English)
+		if a.Score != b.Score || a.Class != b.Class {
+			t.Errorf("Restored model should analyze < %v > the same\n\texpected %+v\n\treturned %+v\n", sentence, a, b)
+		}
+		if r, _ := restored[English].Rating(sentence); r == 0 {
+			t.Errorf("Restored model should still rate < %v >\n", sentence)
+		}
+		if models[Spanish].Predict(sentence) != restored[Spanish].Predict(sentence) {
+			t.Errorf("Restored Bernoulli model should predict < %v > the same\n", sentence)
+		}
+	}
+}
+
+func TestBinaryShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	data, err := binaryModels(t).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Encoding binary models should not return an error!\n\t%v\n", err)
+	}
+
+	// a future version
+	future := append([]byte{}, data...)
+	future[len(binaryMagic)] = BinaryVersion + 1
+	if _, err := RestoreModels(future); err == nil {
+		t.Errorf("Restoring an unknown binary format version should return an error\n")
+	}
+
+	// truncated anywhere, which shouldn't panic
+	for _, n := range []int{len(binaryMagic), len(binaryMagic) + 1, len(data) / 3, len(data) / 2, len(data) - 1} {
+		if _, err := RestoreModels(data[:n]); err == nil {
+			t.Errorf("Restoring binary models truncated to %v bytes should return an error\n", n)
+		}
+	}
+
+	trailing := append(append([]byte{}, data...), 0)
+	if _, err := RestoreModels(trailing); err == nil {
+		t.Errorf("Restoring binary models with bytes past the last model should return an error\n")
+	}
+}
+
+func TestBinaryShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, &TrainOptions{MaxVocabulary: 20})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+	m := models[English]
+
+	// version 2 models listed their pruned
+	// vocabulary rather than flagging it
+	section := &binaryWriter{}
+	section.string("")
+	section.float(m.NeutralThreshold)
+	section.string(string(m.Balance))
+	section.floats(m.Priors)
+	section.string(string(m.Variant))
+	section.float(m.Smoothing)
+	section.uvarints(m.ClassTokens)
+	section.uvarint(uint64(len(m.Vocabulary)))
+	for _, word := range m.Vocabulary {
+		section.string(word)
+	}
+	err = section.table(m.NaiveBayes)
+	if err != nil {
+		t.Fatalf("Encoding a table should not return an error!\n\t%v\n", err)
+	}
+	section.WriteByte(0)
+
+	w := &binaryWriter{}
+	w.Write(binaryMagic)
+	w.uvarint(2)
+	w.uvarint(1)
+	w.string(string(English))
+	w.uvarint(uint64(section.Len()))
+	w.Write(section.Bytes())
+
+	restored, err := RestoreModels(w.Bytes())
+	if err != nil {
+		t.Fatalf("Restoring version 2 binary models should not return an error!\n\t%v\n", err)
+	}
+	if !restored[English].Pruned || !reflect.DeepEqual(restored[English].Vocabulary, m.Vocabulary) {
+		t.Errorf("Version 2 binary models should keep their vocabulary\n\texpected %v\n\treturned %v\n", m.Vocabulary, restored[English].Vocabulary)
+	}
+	if restored[English].Predict("I loved this movie") != m.Predict("I loved this movie") {
+		t.Errorf("Version 2 binary models should predict the same\n")
+	}
+}
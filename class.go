@@ -0,0 +1,65 @@
+package sentiment
+
+import "fmt"
+
+// Class is the sentiment of a document.
+// Models are trained with the classes as
+// their labels, so two class models only
+// know Negative and Positive, and three
+// class models know Neutral as well.
+type Class uint8
+
+// Constants hold the classes sentiment
+// models predict
+const (
+	Negative Class = 0
+	Positive Class = 1
+	Neutral  Class = 2
+)
+
+// SentimentLabels maps the names of the
+// classes to their labels, for use as
+// the Labels of a CSVCorpus or JSONLCorpus
+var SentimentLabels = map[string]uint8{
+	"negative": uint8(Negative),
+	"positive": uint8(Positive),
+	"neutral":  uint8(Neutral),
+}
+
+// String returns the name of the class
+func (c Class) String() string {
+	switch c {
+	case Negative:
+		return "negative"
+	case Positive:
+		return "positive"
+	case Neutral:
+		return "neutral"
+	default:
+		return fmt.Sprintf("class %d", uint8(c))
+	}
+}
+
+// MarshalText marshals the class as its
+// name, so it reads as "positive" (and
+// not 1) in JSON
+func (c Class) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText parses the name of a
+// class, or its number
+func (c *Class) UnmarshalText(text []byte) error {
+	if class, ok := SentimentLabels[string(text)]; ok {
+		*c = Class(class)
+		return nil
+	}
+
+	class, err := parseClass(string(text))
+	if err != nil {
+		return err
+	}
+
+	*c = Class(class)
+	return nil
+}
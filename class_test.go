@@ -0,0 +1,102 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestThreeClassModelShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	result, err := TrainModel(models, English, Documents{
+		{Text: "a wonderful and delightful film", Class: uint8(Positive)},
+		{Text: "lovely music and a happy ending", Class: uint8(Positive)},
+		{Text: "an awful and boring film", Class: uint8(Negative)},
+		{Text: "terrible music and a dreadful ending", Class: uint8(Negative)},
+		{Text: "the ticket was bought on tuesday", Class: uint8(Neutral)},
+		{Text: "the ticket office opens on tuesday", Class: uint8(Neutral)},
+	}, &TrainOptions{Classes: 3})
+	if err != nil {
+		t.Fatalf("Training a three class model should not return an error!\n\t%v\n", err)
+	}
+
+	if len(result.Classes) != 3 || result.Classes[Neutral] != 2 {
+		t.Errorf("Model should have learned 2 neutral documents\n\treturned %v\n", result.Classes)
+	}
+
+	for sentence, class := range map[string]Class{
+		"what a wonderful film":      Positive,
+		"what a boring film":         Negative,
+		"bought a ticket on tuesday": Neutral,
+	} {
+		analysis := models.SentimentAnalysis(sentence, English)
+		if analysis.Class != class || analysis.Score != uint8(class) {
+			t.Errorf("Sentiment of sentence < %v > should be %v\n\treturned %v (score %v)\n", sentence, class, analysis.Class, analysis.Score)
+		}
+	}
+
+	bytes, err := json.Marshal(models.SentimentAnalysis("bought a ticket on tuesday", English))
+	if err != nil {
+		t.Fatalf("Marshalling an analysis should not return an error!\n\t%v\n", err)
+	}
+	if !strings.Contains(string(bytes), `"class":"neutral"`) {
+		t.Errorf("Analysis should marshal its class by name\n\treturned %s\n", bytes)
+	}
+}
+
+func TestNeutralThresholdShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	m := models[English]
+	if class, _ := m.Classify("the film"); class == Neutral {
+		t.Errorf("Binary model without a threshold should never be neutral\n")
+	}
+
+	m.SetNeutralThreshold(0.75)
+
+	analysis := models.SentimentAnalysis("wonderful delightful happy. the film", English)
+	if analysis.Class != Positive {
+		t.Errorf("Confident analysis should stay positive\n\treturned %v\n", analysis.Class)
+	}
+	if len(analysis.Sentences) != 2 || analysis.Sentences[1].Class != Neutral {
+		t.Errorf("Sentence with unknown words should be neutral\n\treturned %+v\n", analysis.Sentences)
+	}
+	if analysis.Sentences[1].Score > 1 {
+		t.Errorf("Neutral fallback should keep the predicted score\n\treturned %v\n", analysis.Sentences[1].Score)
+	}
+
+	class, p := m.Classify("the film")
+	if class != Neutral || p >= 0.75 {
+		t.Errorf("Classify should be neutral below the threshold\n\treturned %v (%v)\n", class, p)
+	}
+}
+
+func TestClassTextShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	for text, expected := range map[string]Class{
+		"negative": Negative,
+		"positive": Positive,
+		"neutral":  Neutral,
+		"2":        Neutral,
+	} {
+		var class Class
+		err := class.UnmarshalText([]byte(text))
+		if err != nil || class != expected {
+			t.Errorf("Class < %v > should parse as %v\n\treturned %v (%v)\n", text, expected, class, err)
+		}
+	}
+
+	var class Class
+	if class.UnmarshalText([]byte("happy")) == nil {
+		t.Errorf("Unknown class names should return an error\n")
+	}
+}
@@ -0,0 +1,261 @@
+// Command sentiment works with sentiment
+// models from the command line.
+//
+//	sentiment uncertain -input comments.txt -n 500 -out queue.jsonl
+//
+// scores every comment with the default
+// model and writes the 500 it's least sure
+// of as a labeling queue. Fill in each
+// line's "label" and train on the queue
+// with sentiment.NewLabelingQueueCorpus.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cdipaolo/sentiment"
+)
+
+const usage = `usage: sentiment <command> [flags]
+
+commands:
+	uncertain	write the documents a model is least sure of as a labeling queue
+	selftrain	adapt a model to a new domain by training on its own predictions
+	convert		convert models between the JSON and binary formats
+	manifest	print the manifests of persisted models
+
+run 'sentiment <command> -h' for a command's flags
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "uncertain":
+		err = uncertain(os.Args[2:])
+	case "selftrain":
+		err = selftrain(os.Args[2:])
+	case "convert":
+		err = convert(os.Args[2:])
+	case "manifest":
+		err = manifest(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command < %v >\n\n%v", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// uncertain runs the uncertain command
+func uncertain(args []string) error {
+	flags := flag.NewFlagSet("uncertain", flag.ExitOnError)
+	modelPath := flags.String("model", "", "models file to score with (default: the built in models)")
+	lang := flags.String("lang", string(sentiment.English), "language of the model to score with")
+	input := flags.String("input", "", "unlabeled corpus to score")
+	format := flags.String("format", "", "format of the input: text, jsonl, csv or tsv (default: from the file extension)")
+	textField := flags.String("text", "text", "JSONL field or CSV column holding the text")
+	n := flags.Int("n", 100, "number of documents to pick")
+	measure := flags.String("measure", string(sentiment.Margin), "uncertainty measure: margin or entropy")
+	diversity := flags.Float64("diversity", 0, "highest similarity (0 to 1) between picked documents, or 0 to pick near duplicates")
+	out := flags.String("out", "", "file to write the labeling queue to (default: stdout)")
+	flags.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("ERROR: no -input corpus to score")
+	}
+
+	models, err := loadModels(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	corpus, err := unlabeledCorpus(*input, *format, *textField)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := models.MostUncertain(corpus, sentiment.Language(*lang), *n, &sentiment.UncertaintyOptions{
+		Measure:   sentiment.Uncertainty(*measure),
+		Diversity: *diversity,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return sentiment.WriteLabelingQueue(os.Stdout, candidates)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+
+	err = sentiment.WriteLabelingQueue(f, candidates)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// selftrain runs the selftrain command
+func selftrain(args []string) error {
+	flags := flag.NewFlagSet("selftrain", flag.ExitOnError)
+	modelPath := flags.String("model", "", "models file to start from (default: the built in models)")
+	lang := flags.String("lang", string(sentiment.English), "language of the model to self-train")
+	input := flags.String("input", "", "directory of unlabeled text from the new domain")
+	test := flags.String("test", "", "directory of held out labeled text, with neg and pos subdirectories, to evaluate each round against")
+	rounds := flags.Int("rounds", 3, "number of rounds of self-training")
+	confidence := flags.Float64("confidence", 0.9, "lowest probability of a prediction to be trained on")
+	out := flags.String("out", "", "file to write the self-trained models to")
+	flags.Parse(args)
+
+	if *input == "" || *out == "" {
+		return fmt.Errorf("ERROR: self-training needs an -input directory and an -out file")
+	}
+
+	models, err := loadModels(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	opts := &sentiment.SelfTrainOptions{
+		Rounds:     *rounds,
+		Confidence: *confidence,
+		Logger:     log.New(os.Stderr, "", log.LstdFlags),
+	}
+	if *test != "" {
+		opts.Test = sentiment.NewDirCorpus(*test, sentiment.IMDBClassDirs)
+	}
+
+	_, err = models.SelfTrain(sentiment.Language(*lang), sentiment.NewUnlabeledDirCorpus(*input), opts)
+	if err != nil {
+		return err
+	}
+
+	return sentiment.PersistToFile(models, *out)
+}
+
+// convert runs the convert command
+func convert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	in := flags.String("in", "", "models file to convert, in either format (default: the built in models)")
+	out := flags.String("out", "", "file to write the converted models to")
+	format := flags.String("format", "", "format to convert to: json or binary (default: binary if -out ends in .bin, otherwise json)")
+	gz := flags.Bool("gzip", false, "compress the converted models with gzip")
+	flags.Parse(args)
+
+	if *out == "" {
+		return fmt.Errorf("ERROR: no -out file to convert to")
+	}
+
+	models, err := loadModels(*in)
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = string(sentiment.FormatJSON)
+		if filepath.Ext(strings.TrimSuffix(*out, ".gz")) == ".bin" {
+			*format = string(sentiment.FormatBinary)
+		}
+	}
+
+	return models.Persist(*out, &sentiment.PersistOptions{
+		EncodeOptions: sentiment.EncodeOptions{
+			Format: sentiment.Format(*format),
+			Gzip:   *gz,
+		},
+	})
+}
+
+// manifest runs the manifest command
+func manifest(args []string) error {
+	flags := flag.NewFlagSet("manifest", flag.ExitOnError)
+	modelPath := flags.String("model", "", "models file to read the manifests of, in either format")
+	flags.Parse(args)
+
+	if *modelPath == "" {
+		return fmt.Errorf("ERROR: no -model file to read")
+	}
+
+	f, err := os.Open(*modelPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifests, err := sentiment.ReadManifests(f)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(manifests, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(os.Stdout, "%s\n", bytes)
+	return err
+}
+
+// loadModels restores the models at path
+// (in either format, gzipped or not), or
+// the built in models if it's empty
+func loadModels(path string) (sentiment.Models, error) {
+	if path == "" {
+		return sentiment.Restore()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return sentiment.Decode(f)
+}
+
+// unlabeledCorpus opens the corpus at path
+// in the given format, reading the text
+// from the given field or column
+func unlabeledCorpus(path, format, textField string) (sentiment.Corpus, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	switch format {
+	case "jsonl":
+		return sentiment.NewJSONLCorpus(path, textField, ""), nil
+	case "csv", "tsv":
+		c := sentiment.NewCSVCorpus(path)
+		if format == "tsv" {
+			c.Comma = '\t'
+		}
+		c.TextColumn, c.LabelColumn = textField, ""
+		return c, nil
+	case "text", "txt":
+		return sentiment.NewTextCorpus(path), nil
+	default:
+		return nil, fmt.Errorf("ERROR: unknown corpus format < %v >, use -format", format)
+	}
+}
@@ -0,0 +1,577 @@
+package sentiment
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cdipaolo/goml/base"
+)
+
+// Document is a single labeled document
+// read out of a Corpus
+type Document struct {
+	// Path is where the document came
+	// from (a file path, a line number,
+	// etc.) and is only used to report
+	// errors back to the caller
+	Path string `json:"path,omitempty"`
+
+	Text  string `json:"text"`
+	Class uint8  `json:"class"`
+
+	// Rating is the document's star rating,
+	// from 1 to MaxRating, or 0 if the
+	// document isn't rated
+	Rating uint8 `json:"rating,omitempty"`
+}
+
+// MaxRating is the highest star rating
+// a document can have (and the number
+// of classes in a star rating model)
+const MaxRating = 10
+
+// Corpus is a labeled collection of
+// documents that models can be trained
+// on (or tested against)
+type Corpus interface {
+	// Walk calls fn once for every
+	// document in the corpus, stopping
+	// at and returning the first error
+	// returned by fn or any error that
+	// keeps the rest of the corpus from
+	// being read
+	Walk(fn WalkFunc) error
+}
+
+// WalkFunc is the type of function called
+// by Corpus.Walk for each document. err is
+// nil for every document that was read. If
+// a single document couldn't be read (it's
+// malformed, unreadable, etc.) fn is called
+// with whatever is known about it and a
+// *DocumentError, and can return nil to skip
+// it and carry on or an error to stop the
+// walk.
+type WalkFunc func(doc Document, err error) error
+
+// DocumentError records a document that
+// couldn't be read or learned, along with
+// where it came from
+type DocumentError struct {
+	Path string `json:"path"`
+	Err  error  `json:"-"`
+}
+
+// Error implements the error interface
+func (e *DocumentError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// skipDocument hands a document that couldn't
+// be read to fn, which decides whether the
+// walk goes on without it
+func skipDocument(fn WalkFunc, doc Document, err error) error {
+	return fn(doc, &DocumentError{
+		Path: doc.Path,
+		Err:  err,
+	})
+}
+
+// Stream walks the corpus on a separate
+// goroutine, sending each document down
+// the returned stream as a
+// base.TextDatapoint so it can be fed
+// straight to text.NewNaiveBayes (or any
+// other goml text model.) The stream is
+// closed once the walk is done, after
+// which the error channel yields the
+// walk's error, if there was one. The
+// first document that can't be read stops
+// the walk.
+func Stream(corpus Corpus) (<-chan base.TextDatapoint, <-chan error) {
+	stream := make(chan base.TextDatapoint, 1000)
+	errors := make(chan error, 1)
+
+	go func() {
+		err := corpus.Walk(func(doc Document, err error) error {
+			if err != nil {
+				return err
+			}
+
+			stream <- base.TextDatapoint{
+				X: doc.Text,
+				Y: doc.Class,
+			}
+			return nil
+		})
+
+		close(stream)
+		if err != nil {
+			errors <- err
+		}
+		close(errors)
+	}()
+
+	return stream, errors
+}
+
+// ClassDirs maps a class to the name
+// of the directory (relative to the
+// corpus root) which holds that
+// class's documents
+type ClassDirs map[uint8]string
+
+// IMDBClassDirs is the layout of the
+// IMDB review datasets shipped in
+// datasets/train and datasets/test
+var IMDBClassDirs = ClassDirs{
+	0: "neg",
+	1: "pos",
+}
+
+// classes returns the classes in the
+// mapping in ascending order so walks
+// are reproducible
+func (c ClassDirs) classes() []uint8 {
+	classes := make([]uint8, 0, len(c))
+	for class := range c {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+
+	return classes
+}
+
+// count returns the number of classes a
+// model needs to hold every class in the
+// mapping (the largest class plus one)
+func (c ClassDirs) count() int {
+	classes := c.classes()
+	if len(classes) == 0 {
+		return 0
+	}
+
+	return int(classes[len(classes)-1]) + 1
+}
+
+// DirCorpus is a Corpus stored as a
+// file tree where every class has its
+// own directory and every file within
+// it is one document. The FS can be
+// anything implementing fs.FS, like
+// os.DirFS, an embed.FS, or a
+// fstest.MapFS.
+type DirCorpus struct {
+	FS      fs.FS
+	Classes ClassDirs
+}
+
+// NewDirCorpus returns a DirCorpus
+// reading from the directory root
+// on disk
+func NewDirCorpus(root string, classes ClassDirs) *DirCorpus {
+	return &DirCorpus{
+		FS:      os.DirFS(root),
+		Classes: classes,
+	}
+}
+
+// NewUnlabeledDirCorpus returns a DirCorpus
+// reading every file under the directory
+// root on disk as an unlabeled document (of
+// class 0), for scoring rather than training
+func NewUnlabeledDirCorpus(root string) *DirCorpus {
+	return NewDirCorpus(root, ClassDirs{0: "."})
+}
+
+// Walk walks every class directory in
+// ascending class order, calling fn
+// with each file's contents. Empty
+// files are skipped, and files that
+// can't be read are passed to fn as
+// errors. A class directory that can't be
+// walked stops the walk.
+//
+// Files named like the IMDB dataset's
+// <id>_<stars>.txt get their star rating
+// parsed out of the name.
+func (c *DirCorpus) Walk(fn WalkFunc) error {
+	return c.walk(true, fn)
+}
+
+// WalkConcurrent is the same as Walk, but
+// reads the files with a pool of workers
+func (c *DirCorpus) WalkConcurrent(workers int, fn WalkFunc) error {
+	return walkOrdered(dirListing{c}, workers, func(doc Document) (Document, error) {
+		var err error
+		doc.Text, err = readDocument(c.FS, doc.Path)
+		return doc, err
+	}, func(doc Document, err error) error {
+		if err != nil {
+			return skipDocument(fn, doc, err)
+		}
+		if doc.Text == "" {
+			return nil
+		}
+
+		return fn(doc, nil)
+	})
+}
+
+// walk walks the class directories. If read
+// is false the files aren't read, and fn is
+// called with every file (even empty ones.)
+func (c *DirCorpus) walk(read bool, fn WalkFunc) error {
+	if c.FS == nil {
+		return fmt.Errorf("ERROR: directory corpus has no filesystem to read from")
+	}
+	if len(c.Classes) == 0 {
+		return fmt.Errorf("ERROR: directory corpus has no class directories")
+	}
+
+	for _, class := range c.Classes.classes() {
+		err := fs.WalkDir(c.FS, c.Classes[class], func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			doc := Document{
+				Path:   path,
+				Class:  class,
+				Rating: ratingFromName(path),
+			}
+			if !read {
+				return fn(doc, nil)
+			}
+
+			doc.Text, err = readDocument(c.FS, path)
+			if err != nil {
+				return skipDocument(fn, doc, err)
+			}
+			if doc.Text == "" {
+				return nil
+			}
+
+			return fn(doc, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dirListing walks the files in a DirCorpus
+// without reading them
+type dirListing struct {
+	c *DirCorpus
+}
+
+func (l dirListing) Walk(fn WalkFunc) error {
+	return l.c.walk(false, fn)
+}
+
+// LineCorpus is a Corpus stored in a single
+// file with one document per line, formatted
+// as
+//
+//	<class>\t<text>
+//
+// like the opinmind dataset of short
+// opinions in datasets/opinmind.txt.
+// Blank lines are skipped.
+type LineCorpus struct {
+	FS   fs.FS
+	Name string
+}
+
+// NewLineCorpus returns a LineCorpus
+// reading from the file at path on disk
+func NewLineCorpus(path string) *LineCorpus {
+	return &LineCorpus{
+		FS:   os.DirFS(filepath.Dir(path)),
+		Name: filepath.Base(path),
+	}
+}
+
+// Walk calls fn with each line of the
+// file, in order. Malformed lines are
+// passed to fn as errors.
+func (c *LineCorpus) Walk(fn WalkFunc) error {
+	if c.FS == nil {
+		return fmt.Errorf("ERROR: line corpus has no filesystem to read from")
+	}
+
+	f, err := c.FS.Open(c.Name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	var line int
+	for scanner.Scan() {
+		line++
+
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		doc := Document{
+			Path: fmt.Sprintf("%v:%v", c.Name, line),
+		}
+
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			err = skipDocument(fn, doc, fmt.Errorf("expected <class>\\t<text>"))
+		} else if doc.Class, err = parseClass(fields[0]); err != nil {
+			err = skipDocument(fn, doc, err)
+		} else {
+			doc.Text = strings.TrimSpace(fields[1])
+			err = fn(doc, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// TextCorpus is an unlabeled corpus stored in
+// a single file with one document per line,
+// like a dump of comments to be scored or
+// labeled. Every document is of class 0 and
+// blank lines are skipped.
+type TextCorpus struct {
+	FS   fs.FS
+	Name string
+}
+
+// NewTextCorpus returns a TextCorpus
+// reading from the file at path on disk
+func NewTextCorpus(path string) *TextCorpus {
+	return &TextCorpus{
+		FS:   os.DirFS(filepath.Dir(path)),
+		Name: filepath.Base(path),
+	}
+}
+
+// Walk calls fn with each line of the
+// file, in order
+func (c *TextCorpus) Walk(fn WalkFunc) error {
+	if c.FS == nil {
+		return fmt.Errorf("ERROR: text corpus has no filesystem to read from")
+	}
+
+	f, err := c.FS.Open(c.Name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	var line int
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		err = fn(Document{
+			Path: fmt.Sprintf("%v:%v", c.Name, line),
+			Text: text,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// maxLineLength is the longest line a
+// line based corpus can hold
+const maxLineLength = 16 * 1024 * 1024
+
+// parseClass parses a class label
+func parseClass(label string) (uint8, error) {
+	class, err := strconv.ParseUint(strings.TrimSpace(label), 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid class < %v >", label)
+	}
+
+	return uint8(class), nil
+}
+
+// Documents is a Corpus held in memory
+type Documents []Document
+
+// Walk calls fn with each document,
+// in order
+func (c Documents) Walk(fn WalkFunc) error {
+	for _, doc := range c {
+		err := fn(doc, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadAll reads every document in the
+// corpus into memory, stopping at the
+// first one that can't be read
+func ReadAll(corpus Corpus) (Documents, error) {
+	var docs Documents
+	err := corpus.Walk(func(doc Document, err error) error {
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// MultiCorpus returns a Corpus that walks
+// each of the given corpora one after the
+// other, letting models be trained off of
+// several datasets at once
+func MultiCorpus(corpora ...Corpus) Corpus {
+	return multiCorpus(corpora)
+}
+
+type multiCorpus []Corpus
+
+func (c multiCorpus) Walk(fn WalkFunc) error {
+	for _, corpus := range c {
+		err := corpus.Walk(fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readDocument reads a whole file into
+// a single line of text (see oneLine)
+func readDocument(fsys fs.FS, path string) (string, error) {
+	bytes, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return "", err
+	}
+
+	return oneLine(string(bytes)), nil
+}
+
+// blankFile returns whether the file holds
+// nothing but whitespace, so readDocument
+// would read it as empty text. It only reads
+// as far as the first rune that isn't a
+// space.
+func blankFile(fsys fs.FS, path string) (bool, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(c) {
+			return false, nil
+		}
+	}
+}
+
+// oneLine turns a document's text into a
+// single line. The tokenizer only splits on
+// spaces, so newlines are turned into
+// spaces to keep the words on either side
+// of them apart.
+func oneLine(text string) string {
+	return strings.TrimSpace(newlines.Replace(text))
+}
+
+// ratingFromName parses the star rating out
+// of a file named <id>_<stars>.<ext>,
+// returning 0 if there isn't a valid one
+func ratingFromName(file string) uint8 {
+	name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+
+	i := strings.LastIndex(name, "_")
+	if i < 0 {
+		return 0
+	}
+
+	stars, err := strconv.Atoi(name[i+1:])
+	if err != nil || stars < 1 || stars > MaxRating {
+		return 0
+	}
+
+	return uint8(stars)
+}
+
+var newlines = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// WalkConcurrent walks each corpus one after
+// the other, reading those that can be read
+// concurrently with the workers
+func (c multiCorpus) WalkConcurrent(workers int, fn WalkFunc) error {
+	for _, corpus := range c {
+		var err error
+		if concurrent, ok := corpus.(ConcurrentCorpus); ok {
+			err = concurrent.WalkConcurrent(workers, fn)
+		} else {
+			err = corpus.Walk(fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package sentiment
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// CSVCorpus is a Corpus stored as a CSV
+// (or TSV, or any other delimited) file
+// with one document per row, like most
+// spreadsheet and annotation tool exports
+type CSVCorpus struct {
+	FS   fs.FS
+	Name string
+
+	// Comma is the field delimiter. It
+	// defaults to ',' (use '\t' for TSV.)
+	Comma rune
+
+	// Header is whether the first row of
+	// the file names the columns (and
+	// isn't a document.)
+	Header bool
+
+	// TextColumn and LabelColumn pick the
+	// columns holding each document's text
+	// and label. They can be column names
+	// from the header or 0-based indices. If
+	// LabelColumn is empty the documents are
+	// unlabeled (and all of class 0), for
+	// scoring rather than training.
+	TextColumn  string
+	LabelColumn string
+
+	// Labels maps label values to classes,
+	// like {"negative": 0, "positive": 1}.
+	// If it's nil labels must be class
+	// numbers.
+	Labels map[string]uint8
+}
+
+// NewCSVCorpus returns a CSVCorpus reading
+// the file at path on disk, with a header
+// and columns named "text" and "label"
+func NewCSVCorpus(path string) *CSVCorpus {
+	return &CSVCorpus{
+		FS:          os.DirFS(filepath.Dir(path)),
+		Name:        filepath.Base(path),
+		Comma:       ',',
+		Header:      true,
+		TextColumn:  "text",
+		LabelColumn: "label",
+	}
+}
+
+// NewTSVCorpus is the same as NewCSVCorpus
+// but for tab separated files
+func NewTSVCorpus(path string) *CSVCorpus {
+	c := NewCSVCorpus(path)
+	c.Comma = '\t'
+
+	return c
+}
+
+// Walk calls fn with each row of the
+// file, in order. Malformed rows are
+// passed to fn as errors.
+func (c *CSVCorpus) Walk(fn WalkFunc) error {
+	if c.FS == nil {
+		return fmt.Errorf("ERROR: CSV corpus has no filesystem to read from")
+	}
+
+	f, err := c.FS.Open(c.Name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if c.Comma != 0 {
+		reader.Comma = c.Comma
+	}
+	if reader.Comma == '\t' {
+		// TSV files don't usually quote
+		// fields, so let stray quotes in
+		reader.LazyQuotes = true
+	}
+
+	var header []string
+	if c.Header {
+		header, err = reader.Read()
+		if err != nil {
+			return fmt.Errorf("%v: could not read header: %v", c.Name, err)
+		}
+		header = append([]string(nil), header...)
+	}
+
+	textI, err := column(header, c.TextColumn)
+	if err != nil {
+		return fmt.Errorf("%v: text column: %v", c.Name, err)
+	}
+	labelI := -1
+	if c.LabelColumn != "" {
+		labelI, err = column(header, c.LabelColumn)
+		if err != nil {
+			return fmt.Errorf("%v: label column: %v", c.Name, err)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			// the reader can pick back up at
+			// the next record
+			err = skipDocument(fn, Document{
+				Path: fmt.Sprintf("%v:%v", c.Name, parseErr.StartLine),
+			}, parseErr.Err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%v: %v", c.Name, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		doc := Document{
+			Path: fmt.Sprintf("%v:%v", c.Name, line),
+		}
+
+		if textI >= len(record) || labelI >= len(record) {
+			err = skipDocument(fn, doc, fmt.Errorf("expected a text and label column, found only %v columns", len(record)))
+		} else if labelI < 0 {
+			doc.Text = oneLine(record[textI])
+			err = fn(doc, nil)
+		} else if doc.Class, err = labelClass(record[labelI], c.Labels); err != nil {
+			err = skipDocument(fn, doc, err)
+		} else {
+			doc.Text = oneLine(record[textI])
+			err = fn(doc, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// column finds a column by its name in
+// the header or, failing that, by index
+func column(header []string, name string) (int, error) {
+	for i := range header {
+		if header[i] == name {
+			return i, nil
+		}
+	}
+
+	i, err := strconv.Atoi(name)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("no column < %v >", name)
+	}
+
+	return i, nil
+}
+
+// labelClass maps a label to its class,
+// either through the labels map or, if
+// there isn't one, by parsing the label
+// as a class number
+func labelClass(label string, labels map[string]uint8) (uint8, error) {
+	if labels == nil {
+		return parseClass(label)
+	}
+
+	class, ok := labels[label]
+	if !ok {
+		return 0, fmt.Errorf("unknown label < %v >", label)
+	}
+
+	return class, nil
+}
@@ -0,0 +1,175 @@
+package sentiment
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JSONLCorpus is a Corpus stored as a JSON
+// Lines file, where every line is a JSON
+// object holding one document
+type JSONLCorpus struct {
+	FS   fs.FS
+	Name string
+
+	// TextField and LabelField are the
+	// paths to each document's text and
+	// label within its object, with nested
+	// fields separated by dots (like
+	// "annotation.label".) Labels can be
+	// strings, numbers, or booleans. If
+	// LabelField is empty the documents are
+	// unlabeled (and all of class 0), for
+	// scoring rather than training.
+	TextField  string
+	LabelField string
+
+	// Labels maps label values to classes,
+	// like {"negative": 0, "positive": 1}.
+	// If it's nil labels must be class
+	// numbers.
+	Labels map[string]uint8
+
+	// SkipUnlabeled skips documents whose
+	// label is missing, null or empty, like
+	// the candidates in a labeling queue no
+	// one has gotten to yet. Otherwise
+	// they're passed to the WalkFunc as
+	// errors.
+	SkipUnlabeled bool
+}
+
+// NewJSONLCorpus returns a JSONLCorpus
+// reading the file at path on disk, with
+// the text and label at the given field
+// paths
+func NewJSONLCorpus(path, textField, labelField string) *JSONLCorpus {
+	return &JSONLCorpus{
+		FS:         os.DirFS(filepath.Dir(path)),
+		Name:       filepath.Base(path),
+		TextField:  textField,
+		LabelField: labelField,
+	}
+}
+
+// Walk calls fn with each line of the
+// file, in order. Blank lines are skipped
+// and malformed lines are passed to fn as
+// errors.
+func (c *JSONLCorpus) Walk(fn WalkFunc) error {
+	if c.FS == nil {
+		return fmt.Errorf("ERROR: JSONL corpus has no filesystem to read from")
+	}
+
+	f, err := c.FS.Open(c.Name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	var line int
+	for scanner.Scan() {
+		line++
+
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		doc := Document{
+			Path: fmt.Sprintf("%v:%v", c.Name, line),
+		}
+
+		doc.Text, doc.Class, err = c.parse(raw)
+		if c.SkipUnlabeled && errors.Is(err, errUnlabeled) {
+			continue
+		}
+		if err != nil {
+			err = skipDocument(fn, doc, err)
+		} else {
+			err = fn(doc, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// errUnlabeled is returned by parse for
+// documents without a label
+var errUnlabeled = errors.New("no label")
+
+// parse pulls the text and class out
+// of a single line's JSON object
+func (c *JSONLCorpus) parse(raw []byte) (string, uint8, error) {
+	var object map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	err := decoder.Decode(&object)
+	if err != nil {
+		return "", 0, err
+	}
+
+	text, ok := field(object, c.TextField).(string)
+	if !ok {
+		return "", 0, fmt.Errorf("no string at < %v >", c.TextField)
+	}
+
+	text = oneLine(text)
+	if c.LabelField == "" {
+		return text, 0, nil
+	}
+
+	var label string
+	switch value := field(object, c.LabelField).(type) {
+	case nil:
+		return "", 0, fmt.Errorf("%w at < %v >", errUnlabeled, c.LabelField)
+	case string:
+		if value == "" {
+			return "", 0, fmt.Errorf("%w at < %v >", errUnlabeled, c.LabelField)
+		}
+		label = value
+	case json.Number:
+		label = value.String()
+	case bool:
+		label = fmt.Sprint(value)
+	default:
+		return "", 0, fmt.Errorf("no label at < %v >", c.LabelField)
+	}
+
+	class, err := labelClass(label, c.Labels)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return text, class, nil
+}
+
+// field follows a dot separated path
+// through nested JSON objects, returning
+// nil if there's nothing there
+func field(object map[string]interface{}, path string) interface{} {
+	var value interface{} = object
+
+	for _, key := range strings.Split(path, ".") {
+		o, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = o[key]
+	}
+
+	return value
+}
@@ -0,0 +1,307 @@
+package sentiment
+
+import (
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cdipaolo/goml/base"
+	"github.com/cdipaolo/goml/text"
+)
+
+func TestLineCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	corpus := &LineCorpus{
+		FS: fstest.MapFS{
+			"opinions.txt": {Data: []byte("1\tI love it.\n\n0\tI hate it.\r\n1\tgood\tstuff\n")},
+		},
+		Name: "opinions.txt",
+	}
+
+	docs, err := ReadAll(corpus)
+	if err != nil {
+		t.Fatalf("Walking a line corpus should not return an error!\n\t%v\n", err)
+	}
+
+	expected := []Document{
+		{Path: "opinions.txt:1", Text: "I love it.", Class: 1},
+		{Path: "opinions.txt:3", Text: "I hate it.", Class: 0},
+		{Path: "opinions.txt:4", Text: "good\tstuff", Class: 1},
+	}
+	if len(docs) != len(expected) {
+		t.Fatalf("Line corpus should have %v documents\n\treturned %+v\n", len(expected), docs)
+	}
+	for i := range docs {
+		if docs[i] != expected[i] {
+			t.Errorf("Document %v should be %+v\n\treturned %+v\n", i, expected[i], docs[i])
+		}
+	}
+}
+
+func TestLineCorpusShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range []string{
+		"1\tfine\nno tab here\n",
+		"positive\tnot a class\n",
+		"300\tout of range\n",
+	} {
+		corpus := &LineCorpus{
+			FS:   fstest.MapFS{"bad.txt": {Data: []byte(data)}},
+			Name: "bad.txt",
+		}
+
+		_, err := ReadAll(corpus)
+		if err == nil {
+			t.Errorf("Walking malformed line corpus < %q > should return an error!\n", data)
+		}
+	}
+}
+
+func TestTextCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	corpus := &TextCorpus{
+		FS: fstest.MapFS{
+			"comments.txt": {Data: []byte("first comment\n\n  \nsecond\tcomment \r\n")},
+		},
+		Name: "comments.txt",
+	}
+
+	docs, err := ReadAll(corpus)
+	if err != nil {
+		t.Fatalf("Walking a text corpus should not return an error!\n\t%v\n", err)
+	}
+
+	expected := []Document{
+		{Path: "comments.txt:1", Text: "first comment"},
+		{Path: "comments.txt:4", Text: "second\tcomment"},
+	}
+	if len(docs) != len(expected) || docs[0] != expected[0] || docs[1] != expected[1] {
+		t.Errorf("Text corpus should have documents %+v\n\treturned %+v\n", expected, docs)
+	}
+}
+
+func TestUnlabeledCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"export.csv":   {Data: []byte("id,text\n1,Great\n2,Awful\n")},
+		"export.jsonl": {Data: []byte(`{"text": "Great"}` + "\n" + `{"text": "Awful", "label": "?"}` + "\n")},
+	}
+
+	for _, corpus := range []Corpus{
+		&CSVCorpus{FS: fsys, Name: "export.csv", Header: true, TextColumn: "text"},
+		&JSONLCorpus{FS: fsys, Name: "export.jsonl", TextField: "text"},
+	} {
+		docs, err := ReadAll(corpus)
+		if err != nil {
+			t.Fatalf("Walking an unlabeled corpus should not return an error!\n\t%v\n", err)
+		}
+
+		if len(docs) != 2 || docs[0].Text != "Great" || docs[1].Text != "Awful" || docs[0].Class != 0 || docs[1].Class != 0 {
+			t.Errorf("Unlabeled corpus should have 2 documents of class 0\n\treturned %+v\n", docs)
+		}
+	}
+}
+
+func TestOpinmindCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	counts := make(map[uint8]int)
+	err := NewLineCorpus("datasets/opinmind.txt").Walk(func(doc Document, err error) error {
+		if err != nil {
+			return err
+		}
+		counts[doc.Class]++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walking the opinmind dataset should not return an error!\n\t%v\n", err)
+	}
+
+	if counts[0] != 3091 || counts[1] != 3995 || len(counts) != 2 {
+		t.Errorf("Opinmind dataset should have 3091 negative and 3995 positive documents\n\treturned %v\n", counts)
+	}
+}
+
+func TestMultiCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	var ct int
+	err := MultiCorpus(
+		&DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs},
+		&LineCorpus{FS: fstest.MapFS{"a.txt": {Data: []byte("1\tgreat\n0\tawful\n")}}, Name: "a.txt"},
+	).Walk(func(_ Document, err error) error {
+		if err != nil {
+			return err
+		}
+		ct++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walking a multi corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if ct != 8 {
+		t.Errorf("Multi corpus should have 8 documents\n\treturned %v\n", ct)
+	}
+}
+
+func TestCSVCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"export.csv": {Data: []byte("id,label,text\n1,positive,\"Great, just great\"\n2,negative,\"Awful\nreally\"\n")},
+		"export.tsv": {Data: []byte("Great \"stuff\"\t1\nAwful stuff\t0\n")},
+	}
+
+	docs, err := ReadAll(&CSVCorpus{
+		FS:          fsys,
+		Name:        "export.csv",
+		Header:      true,
+		TextColumn:  "text",
+		LabelColumn: "label",
+		Labels:      map[string]uint8{"negative": 0, "positive": 1},
+	})
+	if err != nil {
+		t.Fatalf("Walking a CSV corpus should not return an error!\n\t%v\n", err)
+	}
+
+	expected := []Document{
+		{Path: "export.csv:2", Text: "Great, just great", Class: 1},
+		{Path: "export.csv:3", Text: "Awful really", Class: 0},
+	}
+	if len(docs) != len(expected) || docs[0] != expected[0] || docs[1] != expected[1] {
+		t.Errorf("CSV corpus should have documents %+v\n\treturned %+v\n", expected, docs)
+	}
+
+	docs, err = ReadAll(&CSVCorpus{
+		FS:          fsys,
+		Name:        "export.tsv",
+		Comma:       '\t',
+		TextColumn:  "0",
+		LabelColumn: "1",
+	})
+	if err != nil {
+		t.Fatalf("Walking a TSV corpus should not return an error!\n\t%v\n", err)
+	}
+
+	expected = []Document{
+		{Path: "export.tsv:1", Text: "Great \"stuff\"", Class: 1},
+		{Path: "export.tsv:2", Text: "Awful stuff", Class: 0},
+	}
+	if len(docs) != len(expected) || docs[0] != expected[0] || docs[1] != expected[1] {
+		t.Errorf("TSV corpus should have documents %+v\n\treturned %+v\n", expected, docs)
+	}
+}
+
+func TestCSVCorpusShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"export.csv": {Data: []byte("text,label\ngood,1\nbad,neutral\n")},
+	}
+
+	for _, corpus := range []*CSVCorpus{
+		{FS: fsys, Name: "export.csv", Header: true, TextColumn: "text", LabelColumn: "label"},
+		{FS: fsys, Name: "export.csv", Header: true, TextColumn: "body", LabelColumn: "label"},
+		{FS: fsys, Name: "export.csv", Header: true, TextColumn: "text", LabelColumn: "5"},
+		{FS: fsys, Name: "missing.csv", Header: true, TextColumn: "text", LabelColumn: "label"},
+	} {
+		_, err := ReadAll(corpus)
+		if err == nil {
+			t.Errorf("Walking CSV corpus %+v should return an error!\n", corpus)
+		}
+	}
+}
+
+func TestJSONLCorpusShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	corpus := &JSONLCorpus{
+		FS: fstest.MapFS{
+			"export.jsonl": {Data: []byte(`{"review": {"body": "Loved it"}, "label": 1}
+
+{"review": {"body": "Hated\r\nit"}, "label": 0, "extra": [1, 2]}
+`)},
+		},
+		Name:       "export.jsonl",
+		TextField:  "review.body",
+		LabelField: "label",
+	}
+
+	docs, err := ReadAll(corpus)
+	if err != nil {
+		t.Fatalf("Walking a JSONL corpus should not return an error!\n\t%v\n", err)
+	}
+
+	expected := []Document{
+		{Path: "export.jsonl:1", Text: "Loved it", Class: 1},
+		{Path: "export.jsonl:3", Text: "Hated it", Class: 0},
+	}
+	if len(docs) != len(expected) || docs[0] != expected[0] || docs[1] != expected[1] {
+		t.Errorf("JSONL corpus should have documents %+v\n\treturned %+v\n", expected, docs)
+	}
+
+	corpus.LabelField = "review.body"
+	corpus.Labels = map[string]uint8{"Loved it": 1}
+	_, err = ReadAll(corpus)
+	if err == nil {
+		t.Errorf("Walking a JSONL corpus with an unknown label should return an error!\n")
+	}
+}
+
+func TestMultilineTextShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"reviews.csv":   {Data: []byte("text,label\n\"wonderful\nmovie\",1\n\"awful\r\nfilm\",0\n")},
+		"reviews.jsonl": {Data: []byte(`{"text": "wonderful\nmovie", "label": 1}` + "\n" + `{"text": "awful\nfilm", "label": 0}` + "\n")},
+	}
+
+	for _, corpus := range []Corpus{
+		&CSVCorpus{FS: fsys, Name: "reviews.csv", Header: true, TextColumn: "text", LabelColumn: "label"},
+		&JSONLCorpus{FS: fsys, Name: "reviews.jsonl", TextField: "text", LabelField: "label"},
+	} {
+		models := make(Models)
+		_, err := TrainEnglishModelCorpus(models, corpus, &TrainOptions{Strict: true})
+		if err != nil {
+			t.Fatalf("Training off of %T should not return an error!\n\t%v\n", corpus, err)
+		}
+
+		// words on either side of a newline
+		// are learned apart
+		for _, word := range []string{"wonderful", "movie", "awful", "film"} {
+			if _, ok := models[English].Words.Get(word); !ok {
+				t.Errorf("Model trained off of %T should know < %v >\n", corpus, word)
+			}
+		}
+		if _, ok := models[English].Words.Get("wonderfulmovie"); ok {
+			t.Errorf("Model trained off of %T shouldn't join words across lines\n", corpus)
+		}
+	}
+}
+
+func TestStreamShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	stream, errors := Stream(&DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs})
+
+	model := text.NewNaiveBayes(stream, 2, base.OnlyWords)
+	model.Output = ioutil.Discard
+	learnErrors := make(chan error, 10)
+	model.OnlineLearn(learnErrors)
+
+	for err := range learnErrors {
+		t.Errorf("Learning from a corpus stream should not return an error!\n\t%v\n", err)
+	}
+	for err := range errors {
+		t.Errorf("Streaming a corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if model.DocumentCount != 6 {
+		t.Errorf("Model should have seen 6 documents from the stream\n\treturned %v\n", model.DocumentCount)
+	}
+}
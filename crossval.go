@@ -0,0 +1,201 @@
+package sentiment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Configuration is a named set of training
+// options to cross validate
+type Configuration struct {
+	Name    string
+	Options *TrainOptions
+}
+
+// CrossValidation holds the cross validated
+// metrics of a single configuration
+type CrossValidation struct {
+	Name string `json:"name"`
+
+	// Folds holds the evaluation of the model
+	// trained without each fold against
+	// that fold
+	Folds []*Evaluation `json:"folds"`
+
+	// Accuracy and the macro averaged (the
+	// unweighted mean over classes)
+	// Precision, Recall, and F1 across the
+	// folds
+	Accuracy  Stat `json:"accuracy"`
+	Precision Stat `json:"precision"`
+	Recall    Stat `json:"recall"`
+	F1        Stat `json:"f1"`
+}
+
+// Stat is the mean and (sample) standard
+// deviation of a metric across folds
+type Stat struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+}
+
+// String implements the fmt interface
+func (s Stat) String() string {
+	return fmt.Sprintf("%.4f ± %.4f", s.Mean, s.StdDev)
+}
+
+// CrossValidate estimates how well models
+// trained with each configuration generalize
+// using stratified k-fold cross validation.
+// The corpus is split into k folds, each
+// with (close to) the same proportion of
+// every class, shuffled by the seed so runs
+// are reproducible. Then for every fold a
+// model is trained on the other k-1 folds
+// the same way TrainModel trains an English
+// model, and evaluated on the held out fold.
+//
+// All configurations see the same folds, so
+// their results can be compared directly.
+func CrossValidate(corpus Corpus, k int, seed int64, configs ...Configuration) ([]*CrossValidation, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("ERROR: cross validation needs at least 2 folds, not %v", k)
+	}
+
+	docs, err := ReadAll(corpus)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading corpus to cross validate!\n\t%v\n", err)
+	}
+
+	folds, classes := stratify(docs, k, seed)
+	for i := range folds {
+		if len(folds[i]) == 0 {
+			return nil, fmt.Errorf("ERROR: corpus of %v documents is too small for %v folds", len(docs), k)
+		}
+	}
+
+	var results []*CrossValidation
+	for _, config := range configs {
+		cv := &CrossValidation{
+			Name: config.Name,
+		}
+
+		for i := range folds {
+			var train Documents
+			for j := range folds {
+				if j != i {
+					train = append(train, folds[j]...)
+				}
+			}
+
+			models := make(Models)
+			_, err := trainLanguageModel(context.Background(), models, English, train, config.Options.classesFor(classes), config.Options)
+			if err != nil {
+				return nil, fmt.Errorf("Error training configuration < %v > on fold %v!\n\t%v\n", config.Name, i, err)
+			}
+
+			e, err := models[English].Evaluate(folds[i], 0)
+			if err != nil {
+				return nil, fmt.Errorf("Error evaluating configuration < %v > on fold %v!\n\t%v\n", config.Name, i, err)
+			}
+
+			cv.Folds = append(cv.Folds, e)
+		}
+
+		cv.Accuracy = foldStat(cv.Folds, func(e *Evaluation) float64 { return e.Accuracy })
+		cv.Precision = foldStat(cv.Folds, func(e *Evaluation) float64 {
+			return macro(e.Classes, func(c ClassMetrics) float64 { return c.Precision })
+		})
+		cv.Recall = foldStat(cv.Folds, func(e *Evaluation) float64 {
+			return macro(e.Classes, func(c ClassMetrics) float64 { return c.Recall })
+		})
+		cv.F1 = foldStat(cv.Folds, func(e *Evaluation) float64 {
+			return macro(e.Classes, func(c ClassMetrics) float64 { return c.F1 })
+		})
+
+		results = append(results, cv)
+	}
+
+	return results, nil
+}
+
+// stratify splits the documents into k
+// folds, dealing each class's (shuffled)
+// documents out to the folds in turn. It
+// also returns the number of classes a
+// model needs to learn every document.
+func stratify(docs Documents, k int, seed int64) ([]Documents, int) {
+	byClass := make(map[uint8]Documents)
+	classes := 2
+	for _, doc := range docs {
+		byClass[doc.Class] = append(byClass[doc.Class], doc)
+		if int(doc.Class) >= classes {
+			classes = int(doc.Class) + 1
+		}
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	folds := make([]Documents, k)
+
+	// go in class order (not map order)
+	// so the folds are reproducible
+	var next int
+	for class := 0; class < classes; class++ {
+		docs := byClass[uint8(class)]
+		r.Shuffle(len(docs), func(i, j int) {
+			docs[i], docs[j] = docs[j], docs[i]
+		})
+
+		for _, doc := range docs {
+			folds[next] = append(folds[next], doc)
+			next = (next + 1) % k
+		}
+	}
+
+	return folds, classes
+}
+
+// foldStat calculates the mean and sample
+// standard deviation of a metric over
+// the folds
+func foldStat(folds []*Evaluation, metric func(*Evaluation) float64) Stat {
+	var s Stat
+	for _, e := range folds {
+		s.Mean += metric(e)
+	}
+	s.Mean /= float64(len(folds))
+
+	if len(folds) < 2 {
+		return s
+	}
+
+	for _, e := range folds {
+		s.StdDev += math.Pow(metric(e)-s.Mean, 2)
+	}
+	s.StdDev = math.Sqrt(s.StdDev / float64(len(folds)-1))
+
+	return s
+}
+
+// macro averages a per class metric over
+// the classes that have any documents
+func macro(classes []ClassMetrics, metric func(ClassMetrics) float64) float64 {
+	var sum float64
+	var n int
+	for _, c := range classes {
+		if c.Support == 0 {
+			continue
+		}
+
+		sum += metric(c)
+		n++
+	}
+
+	if n == 0 {
+		return 0
+	}
+
+	return sum / float64(n)
+}
@@ -0,0 +1,115 @@
+package sentiment
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestCrossValidateShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	docs, err := ReadAll(NewLineCorpus("datasets/opinmind.txt"))
+	if err != nil {
+		t.Fatalf("Reading the opinmind dataset should not return an error!\n\t%v\n", err)
+	}
+
+	// the dataset is sorted by topic, so
+	// take a sample from all through it
+	var sample Documents
+	for i := 0; i < len(docs); i += 7 {
+		sample = append(sample, docs[i])
+	}
+
+	configs := []Configuration{
+		{Name: "default"},
+		{Name: "letters and numbers", Options: &TrainOptions{
+			Sanitize: func(r rune) bool {
+				return !(r == ' ' || unicode.IsLetter(r) || unicode.IsDigit(r))
+			},
+		}},
+	}
+
+	results, err := CrossValidate(sample, 5, 42, configs...)
+	if err != nil {
+		t.Fatalf("Cross validating should not return an error!\n\t%v\n", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Cross validation should return a result per configuration\n\treturned %v\n", len(results))
+	}
+
+	for _, cv := range results {
+		if len(cv.Folds) != 5 {
+			t.Errorf("Cross validation of < %v > should have 5 folds\n\treturned %v\n", cv.Name, len(cv.Folds))
+		}
+
+		var total int
+		for _, e := range cv.Folds {
+			total += e.Documents
+		}
+		if total != len(sample) {
+			t.Errorf("Folds of < %v > should cover every document once\n\treturned %v\n", cv.Name, total)
+		}
+
+		if cv.Accuracy.Mean < 0.6 || cv.Accuracy.Mean > 1 || cv.Accuracy.StdDev < 0 {
+			t.Errorf("Accuracy of < %v > should be reasonable\n\treturned %v\n", cv.Name, cv.Accuracy)
+		}
+
+		t.Logf("Configuration < %v >\n\taccuracy: %v\n\tprecision: %v\n\trecall: %v\n\tf1: %v\n", cv.Name, cv.Accuracy, cv.Precision, cv.Recall, cv.F1)
+	}
+
+	again, err := CrossValidate(sample, 5, 42, configs[0])
+	if err != nil {
+		t.Fatalf("Cross validating should not return an error!\n\t%v\n", err)
+	}
+	if again[0].Accuracy != results[0].Accuracy {
+		t.Errorf("Cross validation with the same seed should be reproducible\n\treturned %v and %v\n", results[0].Accuracy, again[0].Accuracy)
+	}
+}
+
+func TestCrossValidateShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	// folds are trained like TrainModel trains,
+	// so they get the options' class count
+	results, err := CrossValidate(skewed, 2, 42, Configuration{Name: "neutral", Options: &TrainOptions{Classes: 3}})
+	if err != nil {
+		t.Fatalf("Cross validating should not return an error!\n\t%v\n", err)
+	}
+
+	for i, e := range results[0].Folds {
+		if len(e.Classes) != 3 {
+			t.Errorf("Fold %v should be of a model with 3 classes\n\treturned %v\n", i, len(e.Classes))
+		}
+	}
+}
+
+func TestStratifyShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	var docs Documents
+	for i := 0; i < 30; i++ {
+		docs = append(docs, Document{Class: uint8(i % 3 / 2)})
+	}
+
+	folds, classes := stratify(docs, 4, 1)
+	if classes != 2 {
+		t.Errorf("Stratified documents should have 2 classes\n\treturned %v\n", classes)
+	}
+
+	for i, fold := range folds {
+		var positive int
+		for _, doc := range fold {
+			positive += int(doc.Class)
+		}
+
+		// 10 positive documents over 4 folds
+		if positive < 2 || positive > 3 || len(fold) < 7 || len(fold) > 8 {
+			t.Errorf("Fold %v should have 7-8 documents, 2-3 of them positive\n\treturned %v of %v\n", i, positive, len(fold))
+		}
+	}
+
+	if _, err := CrossValidate(docs[:3], 4, 1); err == nil {
+		t.Errorf("Cross validating with more folds than documents should return an error!\n")
+	}
+}
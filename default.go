@@ -0,0 +1,93 @@
+package sentiment
+
+import (
+	"fmt"
+	"sync"
+)
+
+// the process wide default models, restored
+// the first time they're needed
+var (
+	defaultOnce   sync.Once
+	defaultMu     sync.RWMutex
+	defaultModels Models
+	defaultErr    error
+)
+
+// Default returns the process wide default
+// models. The first call restores the built
+// in models (see Restore) and every call
+// after returns the same ones, so packages
+// sharing them only decompress and parse the
+// embedded model once. It's safe to call
+// from any number of goroutines.
+//
+// Analyzing and learning online with the
+// default models is safe for concurrent use,
+// but they're shared, so don't train or
+// self-train into the map: Restore a copy of
+// your own for that.
+func Default() (Models, error) {
+	defaultOnce.Do(func() {
+		models, err := Restore()
+
+		defaultMu.Lock()
+		defaultModels, defaultErr = models, err
+		defaultMu.Unlock()
+	})
+
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultModels, defaultErr
+}
+
+// SetDefault makes the models the process
+// wide default, in place of the built in
+// ones, like models an application restored
+// from its own file at startup. Calls to
+// Default after it returns the models, and
+// the built in models are never restored if
+// they haven't been already.
+func SetDefault(models Models) error {
+	if len(models) == 0 {
+		return fmt.Errorf("ERROR: you just tried to make an empty set of models the default")
+	}
+	for lang, model := range models {
+		if model == nil || model.NaiveBayes == nil {
+			return fmt.Errorf("ERROR: model for language < %v > is empty", lang)
+		}
+	}
+
+	defaultOnce.Do(func() {})
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultModels, defaultErr = models, nil
+	return nil
+}
+
+// Analyze returns the analysis of the
+// English sentence by the default models
+// (see Default and SentimentAnalysis)
+func Analyze(sentence string) (*Analysis, error) {
+	return AnalyzeLanguage(sentence, English)
+}
+
+// AnalyzeLanguage returns the analysis of
+// the sentence in the language by the
+// default models. Like SentimentAnalysis it
+// falls back on English if there's no model
+// for the language.
+func AnalyzeLanguage(sentence string, lang Language) (*Analysis, error) {
+	models, err := Default()
+	if err != nil {
+		return nil, fmt.Errorf("Error restoring the default sentiment models!\n\t%w\n", err)
+	}
+	if models[lang] == nil && models[English] == nil {
+		return nil, fmt.Errorf("ERROR: the default models have no model for language < %v > or English to fall back on", lang)
+	}
+
+	return models.SentimentAnalysis(sentence, lang), nil
+}
@@ -0,0 +1,92 @@
+package sentiment
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDefaultShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	var wg sync.WaitGroup
+	defaults := make([]Models, 8)
+	for i := range defaults {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			models, err := Default()
+			if err != nil {
+				t.Errorf("Restoring the default models should not return an error!\n\t%v\n", err)
+			}
+			defaults[i] = models
+		}(i)
+	}
+	wg.Wait()
+
+	for i, models := range defaults {
+		if models == nil || reflect.ValueOf(models).Pointer() != reflect.ValueOf(defaults[0]).Pointer() {
+			t.Errorf("Default should return the same models every time (call %v)\n", i)
+		}
+	}
+
+	for _, sentence := range []string{"I had an awesome time watching this movie", "Jeffery is not a fun guy"} {
+		analysis, err := Analyze(sentence)
+		if err != nil {
+			t.Fatalf("Analyzing with the default models should not return an error!\n\t%v\n", err)
+		}
+
+		expected := model.SentimentAnalysis(sentence, English)
+		if analysis.Score != expected.Score || analysis.Class != expected.Class || len(analysis.Words) != len(expected.Words) {
+			t.Errorf("Analyze should analyze < %v > like the built in models\n\texpected %+v\n\treturned %+v\n", sentence, expected, analysis)
+		}
+	}
+}
+
+// not parallel, so it's done before the
+// other tests share the default models
+func TestSetDefaultShouldPass1(t *testing.T) {
+	builtin, err := Default()
+	if err != nil {
+		t.Fatalf("Restoring the default models should not return an error!\n\t%v\n", err)
+	}
+	defer SetDefault(builtin)
+
+	models := make(Models)
+	_, err = TrainModel(models, Spanish, skewed, nil)
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	err = SetDefault(models)
+	if err != nil {
+		t.Fatalf("Setting the default models should not return an error!\n\t%v\n", err)
+	}
+
+	if current, _ := Default(); current[Spanish] != models[Spanish] {
+		t.Errorf("Default should return the models it was set to\n")
+	}
+
+	analysis, err := AnalyzeLanguage("wonderful", Spanish)
+	if err != nil {
+		t.Fatalf("Analyzing with the default models should not return an error!\n\t%v\n", err)
+	}
+	if analysis.Language != Spanish {
+		t.Errorf("Analysis should be by the Spanish model\n\treturned %v\n", analysis.Language)
+	}
+
+	if _, err := Analyze("wonderful"); err == nil {
+		t.Errorf("Analyzing English without an English default model should return an error\n")
+	}
+}
+
+func TestSetDefaultShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	for _, models := range []Models{nil, {}, {Spanish: nil}, {English: &Model{}}} {
+		if err := SetDefault(models); err == nil {
+			t.Errorf("Setting %v as the default models should return an error\n", models)
+		}
+	}
+}
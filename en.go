@@ -1,102 +1,138 @@
 package sentiment
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
-	"time"
-
-	"github.com/cdipaolo/goml/base"
-	"github.com/cdipaolo/goml/text"
 )
 
-// TrainEnglishModel takes in a path to the expected
-// IMDB datasets, and a map of models to add the model
-// to. It'll return any errors if there were any.
+// TrainEnglishModel trains the English model
+// off of the IMDB dataset at datasets/train
+// and adds it to the map of models. This
+// must be run from within the project
+// directory! It'll return any errors if
+// there were any.
+//
+// Training is silent and strict (any
+// document that can't be read is an
+// error.) Use TrainEnglishModelDir with
+// TrainOptions to change that.
 func TrainEnglishModel(modelMap Models) error {
-	pos, posErr := filepath.Abs("datasets/train/pos")
-	neg, negErr := filepath.Abs("datasets/train/neg")
-	if posErr != nil || negErr != nil {
-		return fmt.Errorf("Error getting the IMDB English review dataset from expected paths. Are you in the project directory???\n\tNegative Sample Error: %v\n\tPositive Sample Error: %v\n", negErr, posErr)
-	}
+	return TrainEnglishModelContext(context.Background(), modelMap)
+}
 
-	var ct int
+// TrainEnglishModelContext is the same as
+// TrainEnglishModel but stops early and
+// returns ctx.Err() if ctx is cancelled,
+// leaving the map of models untouched
+func TrainEnglishModelContext(ctx context.Context, modelMap Models) error {
+	root, err := filepath.Abs("datasets/train")
+	if err != nil {
+		return fmt.Errorf("Error getting the IMDB English review dataset from expected paths. Are you in the project directory???\n\t%v\n", err)
+	}
 
-	stream := make(chan base.TextDatapoint, 1000)
-	errors := make(chan error, 100)
-	model := text.NewNaiveBayes(stream, 2, base.OnlyWords)
+	_, err = TrainEnglishModelCorpusContext(ctx, modelMap, NewDirCorpus(root, IMDBClassDirs), &TrainOptions{
+		Strict:      true,
+		Description: "IMDB movie reviews (datasets/train)",
+	})
+	return err
+}
 
-	go model.OnlineLearn(errors)
+// TrainEnglishModelDir trains the English model
+// off of the dataset in the directory root,
+// where classes maps each class to the
+// subdirectory holding its documents (see
+// IMDBClassDirs for the IMDB layout.) This
+// works from any working directory. opts
+// can be nil to train with the defaults.
+func TrainEnglishModelDir(modelMap Models, root string, classes ClassDirs, opts *TrainOptions) (*TrainResult, error) {
+	return TrainEnglishModelFS(modelMap, os.DirFS(root), classes, opts)
+}
 
-	walk := func(dirpath string) error {
-		var class uint8
-		if strings.Contains(dirpath, "pos") {
-			class = uint8(1)
-		}
+// TrainEnglishModelFS is the same as
+// TrainEnglishModelDir but reads the
+// dataset out of any fs.FS, so you can
+// train from an embed.FS or an in-memory
+// fstest.MapFS
+func TrainEnglishModelFS(modelMap Models, fsys fs.FS, classes ClassDirs, opts *TrainOptions) (*TrainResult, error) {
+	return trainLanguageModel(context.Background(), modelMap, English, &DirCorpus{
+		FS:      fsys,
+		Classes: classes,
+	}, opts.classesFor(classes.count()), opts)
+}
 
-		return filepath.Walk(dirpath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
-
-			// read file
-			f, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-
-			scanner := bufio.NewScanner(f)
-			scanner.Split(bufio.ScanLines)
-
-			// only need one line
-			if scanner.Scan() {
-				line := strings.TrimSuffix(scanner.Text(), "\n")
-
-				ct++
-				if ct%500 == 0 {
-					print(".")
-				}
-
-				stream <- base.TextDatapoint{
-					X: line,
-					Y: class,
-				}
-			}
-
-			return nil
-		})
-	}
+// TrainEnglishModelCorpus trains the English
+// model off of any corpus of documents
+// labeled positive (1) or negative (0)
+// (or neutral (2), see TrainOptions.Classes)
+// and adds it to the map of models. opts
+// can be nil to train with the defaults.
+func TrainEnglishModelCorpus(modelMap Models, corpus Corpus, opts *TrainOptions) (*TrainResult, error) {
+	return TrainEnglishModelCorpusContext(context.Background(), modelMap, corpus, opts)
+}
 
-	now := time.Now()
-	fmt.Printf("Starting munging from < %v, %v > to data at %v\n", pos, neg, now)
+// TrainEnglishModelCorpusContext is the same
+// as TrainEnglishModelCorpus but stops walking
+// the corpus and returns ctx.Err() as soon as
+// ctx is cancelled, so retraining can be cut
+// short by a timeout or a shutdown. The map
+// of models is left untouched.
+func TrainEnglishModelCorpusContext(ctx context.Context, modelMap Models, corpus Corpus, opts *TrainOptions) (*TrainResult, error) {
+	return TrainModelContext(ctx, modelMap, English, corpus, opts)
+}
 
-	posErr = walk(pos)
-	negErr = walk(neg)
-	if posErr != nil || negErr != nil {
-		return fmt.Errorf("Error training english sentiment model!\n\tPositive Error: %v\n\tNegative Error: %v\n", posErr, negErr)
+// TrainEnglishOpinmindModel trains the English
+// model off of the short opinions in
+// datasets/opinmind.txt, which does better
+// than the IMDB model on one line comments.
+// If withIMDB is true the IMDB reviews in
+// datasets/train are learned from as well.
+// This must be run from within the project
+// directory. opts can be nil to train with
+// the defaults.
+func TrainEnglishOpinmindModel(modelMap Models, withIMDB bool, opts *TrainOptions) (*TrainResult, error) {
+	opinmind, err := filepath.Abs("datasets/opinmind.txt")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the opinmind English dataset from expected paths. Are you in the project directory???\n\t%v\n", err)
 	}
 
-	close(stream)
+	var corpus Corpus = NewLineCorpus(opinmind)
 
-	for {
-		err, more := <-errors
-		if more {
-			fmt.Printf("Error passed: %v\n", err)
-		} else {
-			// training is done!
-			break
+	if withIMDB {
+		root, err := filepath.Abs("datasets/train")
+		if err != nil {
+			return nil, fmt.Errorf("Error getting the IMDB English review dataset from expected paths. Are you in the project directory???\n\t%v\n", err)
 		}
+
+		corpus = MultiCorpus(corpus, NewDirCorpus(root, IMDBClassDirs))
 	}
 
-	modelMap[English] = model
+	return TrainEnglishModelCorpus(modelMap, corpus, opts)
+}
+
+// TrainEnglishRatingModel trains the star
+// rating classifier of the English model
+// in the map off of the ratings in the
+// IMDB dataset's file names. The English
+// model must already be in the map, and
+// this must be run from within the project
+// directory.
+func TrainEnglishRatingModel(modelMap Models) error {
+	model, ok := modelMap[English]
+	if !ok {
+		return fmt.Errorf("ERROR: train the English model before its rating model")
+	}
 
-	fmt.Printf("\nFinished munging from < %v, %v > to data\n\tdelta: %v\n\taverage time per line: %v\n", pos, neg, time.Now().Sub(now), time.Now().Sub(now)/time.Duration(int64(ct)))
+	root, err := filepath.Abs("datasets/train")
+	if err != nil {
+		return fmt.Errorf("Error getting the IMDB English review dataset from expected paths. Are you in the project directory???\n\t%v\n", err)
+	}
 
-	return nil
+	_, err = model.TrainRatings(NewDirCorpus(root, IMDBClassDirs), &TrainOptions{
+		Strict:      true,
+		Description: "IMDB movie reviews (datasets/train)",
+	})
+	return err
 }
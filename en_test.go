@@ -0,0 +1,119 @@
+package sentiment
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// fixtureFS is a tiny in-memory corpus
+// laid out like the IMDB datasets
+var fixtureFS = fstest.MapFS{
+	"pos/0_9.txt":  {Data: []byte("What a wonderful, delightful and happy movie")},
+	"pos/1_10.txt": {Data: []byte("I loved it, truly wonderful acting.\nA delightful story")},
+	"pos/2_8.txt":  {Data: []byte("Great fun, happy ending and lovely music")},
+	"neg/0_1.txt":  {Data: []byte("What an awful, terrible and boring movie")},
+	"neg/1_2.txt":  {Data: []byte("I hated it, truly terrible acting.\nA boring story")},
+	"neg/2_3.txt":  {Data: []byte("Awful dialogue, horrible ending and dreadful music")},
+	"neg/3_4.txt":  {Data: []byte("")},
+}
+
+func TestTrainEnglishModelFSShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if models[English].DocumentCount != 6 {
+		t.Errorf("Model should have seen 6 (non-empty) documents\n\treturned %v\n", models[English].DocumentCount)
+	}
+
+	for sentence, class := range map[string]uint8{
+		"a wonderful and delightful film": 1,
+		"a terrible and boring film":      0,
+	} {
+		s := models.SentimentAnalysis(sentence, English)
+		if s.Score != class {
+			t.Errorf("Sentiment of sentence < %v > should be %v\n\treturned %v\n", sentence, class, s.Score)
+		}
+	}
+}
+
+func TestTrainEnglishModelFSShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	// the class count comes from the options
+	// like it does training off of any corpus
+	opts := &TrainOptions{Classes: 3}
+	fromFS := make(Models)
+	_, err := TrainEnglishModelFS(fromFS, fixtureFS, IMDBClassDirs, opts)
+	if err != nil {
+		t.Fatalf("Training three classes off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+	fromCorpus := make(Models)
+	_, err = TrainEnglishModelCorpus(fromCorpus, &DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs}, opts)
+	if err != nil {
+		t.Fatalf("Training three classes off of a corpus should not return an error!\n\t%v\n", err)
+	}
+
+	if len(fromFS[English].Count) != 3 || len(fromCorpus[English].Count) != 3 {
+		t.Errorf("Models trained with 3 classes should have 3 classes\n\treturned %v and %v\n", len(fromFS[English].Count), len(fromCorpus[English].Count))
+	}
+}
+
+func TestTrainEnglishModelFSShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, ClassDirs{0: "neg", 1: "missing"}, nil)
+	if err == nil {
+		t.Errorf("Training with a missing class directory should return an error!\n")
+	}
+
+	if _, ok := models[English]; ok {
+		t.Errorf("Failed training should not add a model to the map!\n")
+	}
+}
+
+func TestTrainEnglishModelProgressShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	var reports []Progress
+	buf := &bytes.Buffer{}
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, &TrainOptions{
+		Progress: func(p Progress) {
+			reports = append(reports, p)
+		},
+		ProgressInterval: 2,
+		Logger:           log.New(buf, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	// every 2 documents (of 6) and when done
+	if len(reports) != 4 {
+		t.Fatalf("Training should report progress 4 times\n\treturned %+v\n", reports)
+	}
+
+	last := reports[len(reports)-1]
+	if !last.Done || last.Documents != 6 || last.Classes[0] != 3 || last.Classes[1] != 3 || last.Errors != 0 {
+		t.Errorf("Final progress report should be done with 3 documents of each class\n\treturned %+v\n", last)
+	}
+	for _, p := range reports[:len(reports)-1] {
+		if p.Done {
+			t.Errorf("Only the final progress report should be done\n\treturned %+v\n", p)
+		}
+	}
+
+	if !strings.Contains(buf.String(), "trained on 6 documents") {
+		t.Errorf("Training should log a summary\n\treturned %q\n", buf.String())
+	}
+}
@@ -0,0 +1,157 @@
+package sentiment
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Format is an encoding persisted models
+// can be written in
+type Format string
+
+// Constants hold the model formats. The
+// zero value is FormatJSON.
+const (
+	// FormatJSON is the JSON encoding of
+	// Models, which PersistToFile writes
+	FormatJSON Format = "json"
+
+	// FormatBinary is the compact, versioned
+	// binary format Models.MarshalBinary
+	// writes
+	FormatBinary Format = "binary"
+)
+
+// EncodeOptions configures how models are
+// encoded. A nil (or zero) EncodeOptions
+// writes uncompressed JSON.
+type EncodeOptions struct {
+	Format Format
+
+	// Gzip compresses the models with gzip,
+	// at GzipLevel (gzip.DefaultCompression
+	// if it's zero)
+	Gzip      bool
+	GzipLevel int
+}
+
+// Encode writes the models to w, which can
+// be a file, a network connection, a blob
+// headed for object storage, etc. Decode
+// (or RestoreModels, when uncompressed)
+// reads them back. opts can be nil to write
+// uncompressed JSON.
+func (m Models) Encode(w io.Writer, opts *EncodeOptions) error {
+	format := FormatJSON
+	if opts != nil && opts.Format != "" {
+		format = opts.Format
+	}
+	if format != FormatJSON && format != FormatBinary {
+		return fmt.Errorf("ERROR: unknown model format < %v >", format)
+	}
+
+	if opts != nil && opts.Gzip {
+		level := gzip.DefaultCompression
+		if opts.GzipLevel != 0 {
+			level = opts.GzipLevel
+		}
+
+		gz, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return err
+		}
+
+		err = m.encode(gz, format)
+		if err != nil {
+			return err
+		}
+
+		// closing the gzip writer flushes it
+		// but leaves w open
+		return gz.Close()
+	}
+
+	return m.encode(w, format)
+}
+
+// encode writes the models to w in
+// the format
+func (m Models) encode(w io.Writer, format Format) error {
+	if format == FormatBinary {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(data)
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(m)
+}
+
+// Decode reads models written by Encode
+// from r, working out whether they're JSON
+// or binary and gzipped or not on its own.
+func Decode(r io.Reader) (Models, error) {
+	br, isBinary, err := modelReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var models Models
+	if isBinary {
+		data, err := ioutil.ReadAll(br)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading models!\n\t%w\n", err)
+		}
+
+		models, err = unmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		models = make(Models)
+		err = json.NewDecoder(br).Decode(&models)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding models!\n\t%w\n", err)
+		}
+	}
+
+	err = setupModels(models)
+	if err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// modelReader returns a reader of the
+// models in r, decompressing them if
+// they're gzipped, and whether they're in
+// the binary format
+func modelReader(r io.Reader) (*bufio.Reader, bool, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, false, fmt.Errorf("Error decompressing models!\n\t%w\n", err)
+		}
+
+		br = bufio.NewReader(gz)
+	}
+
+	header, err := br.Peek(len(binaryMagic))
+	if err != nil && err != io.EOF {
+		return nil, false, fmt.Errorf("Error reading models!\n\t%w\n", err)
+	}
+
+	return br, bytes.Equal(header, binaryMagic), nil
+}
@@ -0,0 +1,84 @@
+package sentiment
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncodeShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+	expected, err := json.Marshal(models)
+	if err != nil {
+		t.Fatalf("Marshalling models to JSON should not return an error!\n\t%v\n", err)
+	}
+
+	for _, opts := range []*EncodeOptions{
+		nil,
+		{Format: FormatBinary},
+		{Format: FormatJSON, Gzip: true},
+		{Format: FormatBinary, Gzip: true, GzipLevel: 9},
+	} {
+		var buf bytes.Buffer
+		err := models.Encode(&buf, opts)
+		if err != nil {
+			t.Fatalf("Encoding models with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+
+		// one byte at a time, like a slow
+		// network connection
+		decoded, err := Decode(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+		if err != nil {
+			t.Fatalf("Decoding models encoded with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+
+		returned, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("Marshalling decoded models to JSON should not return an error!\n\t%v\n", err)
+		}
+		if !bytes.Equal(expected, returned) {
+			t.Errorf("Models encoded with %+v should decode to the same models\n", opts)
+		}
+		if decoded[English].Predict("I loved this movie") != models[English].Predict("I loved this movie") {
+			t.Errorf("Models encoded with %+v should predict the same\n", opts)
+		}
+
+		if opts == nil || !opts.Gzip {
+			if _, err := RestoreModels(buf.Bytes()); err != nil {
+				t.Errorf("RestoreModels should read uncompressed models encoded with %+v\n\treturned %v\n", opts, err)
+			}
+		}
+	}
+}
+
+func TestEncodeShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+
+	var buf bytes.Buffer
+	if err := models.Encode(&buf, &EncodeOptions{Format: "yaml"}); err == nil {
+		t.Errorf("Encoding models in an unknown format should return an error\n")
+	}
+
+	buf.Reset()
+	err := models.Encode(&buf, &EncodeOptions{Gzip: true})
+	if err != nil {
+		t.Fatalf("Encoding models should not return an error!\n\t%v\n", err)
+	}
+
+	for _, data := range [][]byte{
+		buf.Bytes()[:buf.Len()/2],
+		[]byte("\x1f\x8bnot really gzip"),
+		[]byte(strings.Repeat("garbage", 3)),
+		nil,
+	} {
+		if _, err := Decode(bytes.NewReader(data)); err == nil {
+			t.Errorf("Decoding %v bytes of malformed models should return an error\n", len(data))
+		}
+	}
+}
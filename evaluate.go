@@ -0,0 +1,302 @@
+package sentiment
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+)
+
+// Evaluation holds how well a model
+// did predicting the classes of a
+// labeled corpus
+type Evaluation struct {
+	Language Language `json:"lang"`
+
+	// Documents is the number of documents
+	// evaluated, Correct the number the
+	// model predicted the right class of
+	Documents int     `json:"documents"`
+	Correct   int     `json:"correct"`
+	Accuracy  float64 `json:"accuracy"`
+
+	// Classes holds the metrics for each
+	// class the model predicts, indexed
+	// by class
+	Classes []ClassMetrics `json:"classes"`
+
+	// Confusion is the confusion matrix,
+	// where Confusion[i][j] is the number
+	// of documents of class i that were
+	// predicted to be class j
+	Confusion [][]int `json:"confusion"`
+
+	// Misclassified holds the documents the
+	// model was most confidently wrong about,
+	// most confident first
+	Misclassified []Misclassification `json:"misclassified,omitempty"`
+
+	// Failed holds the documents that
+	// couldn't be read or evaluated and
+	// were skipped, like training skips them
+	Failed []*DocumentError `json:"failed,omitempty"`
+}
+
+// ClassMetrics holds the precision,
+// recall, and F1 score of a model for
+// a single class
+type ClassMetrics struct {
+	Class     uint8   `json:"class"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+
+	// Support is the number of documents
+	// in the corpus of this class
+	Support int `json:"support"`
+}
+
+// Misclassification is a document the
+// model predicted the wrong class for
+type Misclassification struct {
+	Document
+
+	// Predicted is the class the model
+	// predicted, with the probability it
+	// gave that class as Confidence
+	Predicted  uint8   `json:"predicted"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Evaluate runs the model for the given
+// language over every document in the
+// corpus, comparing its predictions to the
+// corpus labels. The worst misclassified
+// documents (those the model was most
+// confident about) are kept, up to worst
+// of them (none if worst isn't positive.)
+// Documents that can't be read, or are of
+// a class the model doesn't have, are
+// skipped and recorded in Failed.
+func (m Models) Evaluate(corpus Corpus, lang Language, worst int) (*Evaluation, error) {
+	model, ok := m[lang]
+	if !ok {
+		return nil, fmt.Errorf("ERROR: no model for language < %v > to evaluate", lang)
+	}
+
+	e, err := model.Evaluate(corpus, worst)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Language = lang
+
+	return e, nil
+}
+
+// Evaluate runs the model over every document
+// in the corpus, the same as Models.Evaluate
+func (m *Model) Evaluate(corpus Corpus, worst int) (*Evaluation, error) {
+	classes := len(m.Count)
+	e := &Evaluation{
+		Confusion: make([][]int, classes),
+	}
+	for i := range e.Confusion {
+		e.Confusion[i] = make([]int, classes)
+	}
+
+	// only the worst misclassifications seen
+	// so far are kept, so a big corpus doesn't
+	// hold every one of them in memory
+	misclassified := &misclassificationHeap{}
+	var seq int
+
+	err := corpus.Walk(func(doc Document, err error) error {
+		if err != nil {
+			failed, ok := err.(*DocumentError)
+			if !ok {
+				failed = &DocumentError{Path: doc.Path, Err: err}
+			}
+			e.Failed = append(e.Failed, failed)
+			return nil
+		}
+		if int(doc.Class) >= classes {
+			e.Failed = append(e.Failed, &DocumentError{
+				Path: doc.Path,
+				Err:  fmt.Errorf("class %v is out of range, the model only has %v classes", doc.Class, classes),
+			})
+			return nil
+		}
+
+		m.mu.RLock()
+		predicted, probs := m.probabilities(doc.Text)
+		m.mu.RUnlock()
+
+		e.Documents++
+		e.Confusion[doc.Class][predicted]++
+		if predicted == doc.Class {
+			e.Correct++
+			return nil
+		}
+
+		if worst <= 0 {
+			return nil
+		}
+
+		miss := rankedMisclassification{
+			Misclassification: Misclassification{
+				Document:   doc,
+				Predicted:  predicted,
+				Confidence: probs[predicted],
+			},
+			seq: seq,
+		}
+		seq++
+
+		switch {
+		case misclassified.Len() < worst:
+			heap.Push(misclassified, miss)
+		case misclassified.less(misclassified.items[0], miss):
+			misclassified.items[0] = miss
+			heap.Fix(misclassified, 0)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error evaluating sentiment model!\n\t%v\n", err)
+	}
+
+	if e.Documents > 0 {
+		e.Accuracy = float64(e.Correct) / float64(e.Documents)
+	}
+
+	e.Classes = classMetrics(e.Confusion)
+
+	sort.Slice(misclassified.items, func(i, j int) bool {
+		return misclassified.less(misclassified.items[j], misclassified.items[i])
+	})
+	for _, miss := range misclassified.items {
+		e.Misclassified = append(e.Misclassified, miss.Misclassification)
+	}
+
+	return e, nil
+}
+
+// rankedMisclassification is a
+// misclassification along with the order
+// it was found in
+type rankedMisclassification struct {
+	Misclassification
+	seq int
+}
+
+// misclassificationHeap is a min-heap of
+// misclassifications, with the least
+// confident on top
+type misclassificationHeap struct {
+	items []rankedMisclassification
+}
+
+// less returns whether a is less of a
+// mistake than b. Of equally confident
+// mistakes the earlier one is kept.
+func (h *misclassificationHeap) less(a, b rankedMisclassification) bool {
+	if a.Confidence != b.Confidence {
+		return a.Confidence < b.Confidence
+	}
+	return a.seq > b.seq
+}
+
+func (h *misclassificationHeap) Len() int { return len(h.items) }
+
+func (h *misclassificationHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+
+func (h *misclassificationHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *misclassificationHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(rankedMisclassification))
+}
+
+func (h *misclassificationHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// EvaluateEnglishModel evaluates the English
+// model against the IMDB test dataset at
+// datasets/test. Like TrainEnglishModel this
+// must be run from within the project
+// directory.
+func EvaluateEnglishModel(m Models, worst int) (*Evaluation, error) {
+	root, err := filepath.Abs("datasets/test")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the IMDB English review test dataset from expected paths. Are you in the project directory???\n\t%v\n", err)
+	}
+
+	return m.Evaluate(NewDirCorpus(root, IMDBClassDirs), English, worst)
+}
+
+// classMetrics calculates per class metrics
+// from a confusion matrix
+func classMetrics(confusion [][]int) []ClassMetrics {
+	metrics := make([]ClassMetrics, len(confusion))
+
+	for c := range confusion {
+		var predicted int
+		for actual := range confusion {
+			predicted += confusion[actual][c]
+		}
+
+		for _, n := range confusion[c] {
+			metrics[c].Support += n
+		}
+
+		truePositives := float64(confusion[c][c])
+
+		metrics[c].Class = uint8(c)
+		if predicted > 0 {
+			metrics[c].Precision = truePositives / float64(predicted)
+		}
+		if metrics[c].Support > 0 {
+			metrics[c].Recall = truePositives / float64(metrics[c].Support)
+		}
+		if metrics[c].Precision+metrics[c].Recall > 0 {
+			metrics[c].F1 = 2 * metrics[c].Precision * metrics[c].Recall / (metrics[c].Precision + metrics[c].Recall)
+		}
+	}
+
+	return metrics
+}
+
+// String implements the fmt interface for
+// clean printing of an evaluation as a
+// small report
+func (e *Evaluation) String() string {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "Evaluation of < %v > model:\n\tDocuments: %v\n\tAccuracy: %.4f\n", e.Language, e.Documents, e.Accuracy)
+	if len(e.Failed) > 0 {
+		fmt.Fprintf(buf, "\tFailed: %v\n", len(e.Failed))
+	}
+	fmt.Fprintf(buf, "\n")
+
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "\tclass\tprecision\trecall\tf1\tsupport\n")
+	for _, c := range e.Classes {
+		fmt.Fprintf(w, "\t%v\t%.4f\t%.4f\t%.4f\t%v\n", c.Class, c.Precision, c.Recall, c.F1, c.Support)
+	}
+	w.Flush()
+
+	fmt.Fprintf(buf, "\nConfusion (actual x predicted):\n")
+	for i, row := range e.Confusion {
+		fmt.Fprintf(buf, "\t%v: %v\n", i, row)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,162 @@
+package sentiment
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestEvaluateShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	corpus := &DirCorpus{
+		FS: fstest.MapFS{
+			"pos/0.txt": {Data: []byte("a wonderful and delightful film")},
+			"pos/1.txt": {Data: []byte("happy and lovely")},
+			"pos/2.txt": {Data: []byte("terrible and awful but I loved it")},
+			"neg/0.txt": {Data: []byte("a boring and terrible film")},
+			"neg/1.txt": {Data: []byte("dreadful and horrible")},
+		},
+		Classes: IMDBClassDirs,
+	}
+
+	e, err := models.Evaluate(corpus, English, 10)
+	if err != nil {
+		t.Fatalf("Evaluating a model should not return an error!\n\t%v\n", err)
+	}
+
+	if e.Documents != 5 || e.Correct != 4 {
+		t.Errorf("Evaluation should have 4/5 documents correct\n\treturned %v/%v\n", e.Correct, e.Documents)
+	}
+	if e.Accuracy != 0.8 {
+		t.Errorf("Accuracy should be 0.8\n\treturned %v\n", e.Accuracy)
+	}
+
+	if e.Confusion[0][0] != 2 || e.Confusion[1][1] != 2 || e.Confusion[1][0] != 1 || e.Confusion[0][1] != 0 {
+		t.Errorf("Confusion matrix is wrong\n\treturned %v\n", e.Confusion)
+	}
+
+	if e.Classes[0].Precision != 2.0/3.0 || e.Classes[0].Recall != 1 || e.Classes[1].Precision != 1 || e.Classes[1].Recall != 2.0/3.0 {
+		t.Errorf("Per class metrics are wrong\n\treturned %+v\n", e.Classes)
+	}
+
+	if len(e.Misclassified) != 1 || e.Misclassified[0].Path != "pos/2.txt" || e.Misclassified[0].Predicted != 0 {
+		t.Errorf("Misclassified documents are wrong\n\treturned %+v\n", e.Misclassified)
+	}
+
+	t.Logf("Evaluation:\n%v\n", e)
+}
+
+func TestEvaluateShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	// everything flipped, so every document
+	// is misclassified
+	corpus := &DirCorpus{FS: fixtureFS, Classes: ClassDirs{0: "pos", 1: "neg"}}
+
+	all, err := models.Evaluate(corpus, English, 1000)
+	if err != nil {
+		t.Fatalf("Evaluating a model should not return an error!\n\t%v\n", err)
+	}
+	if len(all.Misclassified) != all.Documents-all.Correct {
+		t.Fatalf("Every misclassified document should be kept\n\treturned %v of %v\n", len(all.Misclassified), all.Documents-all.Correct)
+	}
+	for i := 1; i < len(all.Misclassified); i++ {
+		if all.Misclassified[i].Confidence > all.Misclassified[i-1].Confidence {
+			t.Errorf("Misclassified documents should be most confident first\n\treturned %+v\n", all.Misclassified)
+			break
+		}
+	}
+
+	for _, worst := range []int{-1, 0, 1, 3} {
+		e, err := models.Evaluate(corpus, English, worst)
+		if err != nil {
+			t.Fatalf("Evaluating a model keeping the %v worst should not return an error!\n\t%v\n", worst, err)
+		}
+
+		expected := all.Misclassified
+		if worst < len(expected) {
+			expected = expected[:0]
+			if worst > 0 {
+				expected = all.Misclassified[:worst]
+			}
+		}
+		if len(e.Misclassified) != len(expected) {
+			t.Fatalf("Evaluation should keep the %v worst documents\n\treturned %v\n", worst, len(e.Misclassified))
+		}
+		for i := range expected {
+			if e.Misclassified[i].Path != expected[i].Path {
+				t.Errorf("Evaluation should keep the %v worst documents in order\n\texpected %+v\n\treturned %+v\n", worst, expected, e.Misclassified)
+				break
+			}
+		}
+	}
+}
+
+func TestEvaluateShouldPass3(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	// a line that isn't JSON and a class the
+	// model doesn't have are skipped, like
+	// training skips them
+	corpus := MultiCorpus(&JSONLCorpus{
+		FS: fstest.MapFS{"docs.jsonl": {Data: []byte(`{"text": "a wonderful film", "label": 1}
+{"text": "a boring
+{"text": "a terrible film", "label": 0}
+`)}},
+		Name:       "docs.jsonl",
+		TextField:  "text",
+		LabelField: "label",
+	}, Documents{{Path: "neutral", Text: "an okay film", Class: 2}})
+
+	e, err := models.Evaluate(corpus, English, 0)
+	if err != nil {
+		t.Fatalf("Evaluating a corpus with bad documents should not return an error!\n\t%v\n", err)
+	}
+	if e.Documents != 2 || e.Correct != 2 {
+		t.Errorf("Evaluation should have 2/2 documents correct\n\treturned %v/%v\n", e.Correct, e.Documents)
+	}
+	if len(e.Failed) != 2 || e.Failed[1].Path != "neutral" {
+		t.Errorf("Evaluation should record both bad documents\n\treturned %v\n", e.Failed)
+	}
+
+	models[English].RecordEvaluation(e)
+	if models[English].Manifest.Evaluation.Failed != nil {
+		t.Errorf("Recorded evaluation should leave out its failed documents\n")
+	}
+}
+
+func TestProbabilitiesShouldMatchPredict1(t *testing.T) {
+	t.Parallel()
+
+	for _, sentence := range []string{
+		"I had an awesome time watching this movie",
+		"Jeffery is not a fun guy",
+		"",
+	} {
+		class, probs := model[English].probabilities(sentence)
+		if class != model[English].Predict(sentence) {
+			t.Errorf("Class of < %v > should match Predict\n\treturned %v\n", sentence, class)
+		}
+		if probs[class] < 0.5 {
+			t.Errorf("Probability of the predicted class of < %v > should be at least 0.5\n\treturned %v\n", sentence, probs)
+		}
+	}
+}
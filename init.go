@@ -1,19 +1,22 @@
 package sentiment
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"os"
-	"path"
 
-	"github.com/cdipaolo/goml/base"
 	"github.com/cdipaolo/goml/text"
 )
 
 const (
 	// TempDirectory is the default temporary
 	// directory for persisting models to disk
+	//
+	// Deprecated: Train no longer persists the
+	// models it trains. Persist them wherever
+	// you'd like with PersistToFile.
 	TempDirectory string = "/tmp/.sentiment"
 )
 
@@ -36,69 +39,214 @@ func Restore() (Models, error) {
 // a (presumably) map[Language]LanguageModel
 // and marshals it into a usable model that
 // you can use to run regular, language
-// specific sentiment analysis
+// specific sentiment analysis. The bytes can
+// be JSON or the binary format written by
+// Models.MarshalBinary.
+//
+// Each model gets the default sanitizer and
+// tokenizer for its language, so models
+// trained with anything else need them set
+// again with UpdateSanitize and
+// UpdateTokenizer.
 func RestoreModels(bytes []byte) (Models, error) {
-	models := make(Models)
-	err := json.Unmarshal(bytes, &models)
+	var models Models
+	var err error
+	if isBinary(bytes) {
+		models, err = unmarshalBinary(bytes)
+	} else {
+		models = make(Models)
+		err = json.Unmarshal(bytes, &models)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	tokenizer := &text.SimpleTokenizer{
-		SplitOn: " ",
+	err = setupModels(models)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range models {
-		models[i].UpdateSanitize(base.OnlyWords)
-		models[i].UpdateTokenizer(tokenizer)
+	return models, nil
+}
+
+// setupModels gives freshly decoded models
+// their language's default sanitizer and
+// tokenizer, and precomputes what their
+// variants need to score documents
+func setupModels(models Models) error {
+	for lang, model := range models {
+		if model == nil || model.NaiveBayes == nil {
+			return fmt.Errorf("ERROR: model for language < %v > is empty", lang)
+		}
+
+		tokenizer := DefaultTokenizer(lang)
+		model.UpdateSanitize(DefaultSanitizer(lang))
+		model.UpdateTokenizer(tokenizer)
+		model.Output = ioutil.Discard
+
+		err := model.prepareVariant()
+		if err != nil {
+			return err
+		}
+
+		if model.Ratings != nil {
+			model.Ratings.UpdateTokenizer(tokenizer)
+			model.Ratings.Output = ioutil.Discard
+		}
 	}
 
-	return models, nil
+	return nil
 }
 
-// PersistToFile persists a Models struct to
-// a filepath, returning any errors
-func PersistToFile(m Models, path string) error {
-	if path == "" {
-		return fmt.Errorf("ERROR: you just tried to persist your model to a file with no path!! That's a no-no. Try it with a valid filepath")
+// MarshalJSON marshals the models to JSON,
+// giving each model's manifest its language
+func (m Models) MarshalJSON() ([]byte, error) {
+	models := make(map[Language]json.RawMessage, len(m))
+	for lang, model := range m {
+		if model == nil {
+			models[lang] = json.RawMessage("null")
+			continue
+		}
+
+		data, err := model.marshalJSON(lang)
+		if err != nil {
+			return nil, err
+		}
+		models[lang] = data
 	}
 
-	bytes, err := json.Marshal(m)
+	return json.Marshal(models)
+}
+
+// MarshalJSON marshals the model to JSON,
+// holding its lock so it can be persisted
+// while it's learning
+func (m *Model) MarshalJSON() ([]byte, error) {
+	return m.marshalJSON(NoLanguage)
+}
+
+// marshalJSON marshals the model to JSON
+// with its manifest filled in
+func (m *Model) marshalJSON(lang Language) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, err := m.marshalContent()
+	if err != nil || m.NaiveBayes == nil {
+		return data, err
+	}
+
+	// the hash is of the JSON already
+	// encoded, so the manifest is spliced
+	// onto the end of it rather than
+	// encoding the model all over again
+	manifest, err := json.Marshal(m.manifest(lang, FormatJSON, contentHash(data)))
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	withManifest := make([]byte, 0, len(data)+len(manifest)+len(`,"manifest":`))
+	withManifest = append(withManifest, data[:len(data)-1]...)
+	if len(data) > len("{}") {
+		withManifest = append(withManifest, ',')
 	}
+	withManifest = append(withManifest, `"manifest":`...)
+	withManifest = append(withManifest, manifest...)
+	withManifest = append(withManifest, '}')
+
+	return withManifest, nil
+}
 
-	err = ioutil.WriteFile(path, bytes, os.ModePerm)
+// marshalContent marshals the model to JSON
+// without its manifest, which is what the
+// hash of a JSON model is of. The caller
+// must hold the model's lock.
+func (m *Model) marshalContent() ([]byte, error) {
+	type model Model
+
+	aux := struct {
+		*model
+		Manifest *Manifest `json:"manifest,omitempty"`
+	}{
+		model: (*model)(m),
+	}
+
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON restores a Model from JSON.
+// A NaiveBayes model's tokenizer is an
+// interface, which encoding/json can't
+// decode into on its own, so it gets decoded
+// into a SimpleTokenizer until RestoreModels
+// sets the language's default tokenizer.
+func (m *Model) UnmarshalJSON(data []byte) error {
+	type model Model
+	aux := struct {
+		*model
+		Ratings json.RawMessage `json:"ratings,omitempty"`
+	}{
+		model: (*model)(m),
+	}
+
+	m.NaiveBayes = &text.NaiveBayes{
+		Tokenizer: &text.SimpleTokenizer{},
+	}
+
+	err := json.Unmarshal(data, &aux)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	m.Ratings = nil
+	if len(aux.Ratings) != 0 && string(aux.Ratings) != "null" {
+		m.Ratings = &text.NaiveBayes{
+			Tokenizer: &text.SimpleTokenizer{},
+		}
+		err = json.Unmarshal(aux.Ratings, m.Ratings)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.restoreVocabulary()
+}
+
+// PersistToFile persists a Models struct to
+// a filepath as JSON, returning any errors.
+// The file is replaced atomically and
+// written with DefaultPerm (see
+// Models.Persist.)
+func PersistToFile(m Models, path string) error {
+	return m.Persist(path, nil)
 }
 
-// Train takes in a directory path to persist the model
-// to, trains the model, and saves the model to
-// the given file. After this is run you can
+// Train trains the models off of the IMDB
+// dataset. After this is run you can
 // run the SentimentXXX functions effectively.
+// Nothing is written to disk, so persist the
+// models with PersistToFile (or
+// Models.Persist) to keep them.
 //
 // Note that this must be run from within the project
 // directory! To just get the model without re-training
 // you should just call "Resore"
 func Train() (Models, error) {
-	models := make(Models)
-	err := TrainEnglishModel(models)
-	if err != nil {
-		return nil, fmt.Errorf("Count not train English sentiment model!\n\t%v\n", err)
-	}
+	return TrainContext(context.Background())
+}
 
-	err = os.MkdirAll(TempDirectory, os.ModePerm)
-	if err != nil {
-		return nil, fmt.Errorf("Count not create temp directory!\n\t%v\n", err)
+// TrainContext is the same as Train but
+// stops early and returns ctx.Err() if ctx
+// is cancelled before the models are
+// trained
+func TrainContext(ctx context.Context) (Models, error) {
+	models := make(Models)
+	err := TrainEnglishModelContext(ctx, models)
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return nil, ctx.Err()
 	}
-
-	err = PersistToFile(models, path.Join(TempDirectory, "model.json"))
 	if err != nil {
-		return nil, fmt.Errorf("Could not persist m.Words to JSON!\n\t%v\n", err)
+		return nil, fmt.Errorf("Count not train English sentiment model!\n\t%v\n", err)
 	}
 
 	return models, nil
@@ -0,0 +1,155 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/cdipaolo/goml/base"
+	"github.com/cdipaolo/goml/text"
+)
+
+// TrainModel trains a sentiment model for
+// any language off of a corpus of documents
+// labeled positive (1) or negative (0), or
+// neutral (2) with opts.Classes set to 3, and
+// adds it to the map of models, replacing
+// any model the language had before. opts
+// can be nil to train with the defaults.
+// Unless opts says otherwise, documents are
+// sanitized and tokenized with the defaults
+// for the language (see DefaultSanitizer
+// and DefaultTokenizer.)
+func TrainModel(modelMap Models, lang Language, corpus Corpus, opts *TrainOptions) (*TrainResult, error) {
+	return TrainModelContext(context.Background(), modelMap, lang, corpus, opts)
+}
+
+// TrainModelContext is the same as TrainModel
+// but stops walking the corpus and returns
+// ctx.Err() as soon as ctx is cancelled. The
+// map of models is left untouched.
+func TrainModelContext(ctx context.Context, modelMap Models, lang Language, corpus Corpus, opts *TrainOptions) (*TrainResult, error) {
+	return trainLanguageModel(ctx, modelMap, lang, corpus, opts.classes(), opts)
+}
+
+// trainLanguageModel trains a model for the
+// language with the given number of classes
+// off of the corpus and adds it to the map
+// of models
+func trainLanguageModel(ctx context.Context, modelMap Models, lang Language, corpus Corpus, classes int, opts *TrainOptions) (*TrainResult, error) {
+	langOpts := TrainOptions{}
+	if opts != nil {
+		langOpts = *opts
+	}
+	if langOpts.Sanitize == nil {
+		langOpts.Sanitize = DefaultSanitizer(lang)
+	}
+	if langOpts.Tokenizer == nil {
+		langOpts.Tokenizer = DefaultTokenizer(lang)
+	}
+
+	model, result, err := trainModel(ctx, corpus, classes, &langOpts)
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return result, ctx.Err()
+	}
+	if err != nil {
+		return result, fmt.Errorf("Error training %v sentiment model!\n\t%w\n", languageName(lang), err)
+	}
+
+	model.Manifest = newManifest(lang, corpus, classes, &langOpts)
+	modelMap[lang] = model
+
+	return result, nil
+}
+
+// DefaultSanitizer returns the sanitization
+// function models of the language are
+// trained with by default. That's
+// base.OnlyWords, except for scripts whose
+// vowels are written with combining marks
+// (like Hindi and Thai) where it's
+// OnlyWordsAndMarks.
+func DefaultSanitizer(lang Language) func(rune) bool {
+	switch lang {
+	case Hindi, Thai:
+		return OnlyWordsAndMarks
+	default:
+		return base.OnlyWords
+	}
+}
+
+// DefaultTokenizer returns the tokenizer
+// models of the language are trained with
+// by default. Languages which don't put
+// spaces between words (Chinese, Japanese
+// and Thai) are split into character
+// n-grams, and everything else is split
+// on spaces.
+func DefaultTokenizer(lang Language) text.Tokenizer {
+	switch lang {
+	case ChineseSimplified, ChineseTraditional, Japanese:
+		return &NGramTokenizer{N: 2}
+	case Thai:
+		return &NGramTokenizer{N: 3}
+	default:
+		return &text.SimpleTokenizer{
+			SplitOn: " ",
+		}
+	}
+}
+
+// OnlyWordsAndMarks is a sanitization
+// function like base.OnlyWords that also
+// keeps combining marks, which many
+// scripts (like Devanagari and Thai)
+// write vowels with
+func OnlyWordsAndMarks(r rune) bool {
+	return !(r == ' ' || unicode.IsLetter(r) || unicode.IsMark(r))
+}
+
+// NGramTokenizer splits sentences into
+// overlapping runs of N characters for
+// languages that don't put spaces between
+// their words, like Chinese and Japanese.
+// Whitespace still separates tokens, and
+// anything shorter than N characters is
+// kept whole.
+type NGramTokenizer struct {
+	N int
+}
+
+// Tokenize splits the lower cased sentence
+// into character n-grams
+func (t *NGramTokenizer) Tokenize(sentence string) []string {
+	n := t.N
+	if n < 1 {
+		n = 1
+	}
+
+	var tokens []string
+	for _, field := range strings.Fields(strings.ToLower(sentence)) {
+		runes := []rune(field)
+		if len(runes) <= n {
+			tokens = append(tokens, field)
+			continue
+		}
+
+		for i := 0; i+n <= len(runes); i++ {
+			tokens = append(tokens, string(runes[i:i+n]))
+		}
+	}
+
+	return tokens
+}
+
+// languageName names the language in
+// error messages
+func languageName(lang Language) string {
+	if lang == English {
+		return "english"
+	}
+
+	return fmt.Sprintf("< %v >", lang)
+}
@@ -0,0 +1,92 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTrainModelShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, Spanish, Documents{
+		{Text: "una película maravillosa y encantadora", Class: 1},
+		{Text: "qué historia tan bonita y maravillosa", Class: 1},
+		{Text: "una película horrible y aburrida", Class: 0},
+		{Text: "qué historia tan aburrida y terrible", Class: 0},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Training a Spanish model should not return an error!\n\t%v\n", err)
+	}
+
+	if _, ok := models[English]; ok {
+		t.Errorf("Training a Spanish model should not add an English one\n")
+	}
+
+	for sentence, class := range map[string]uint8{
+		"Maravillosa película":  1,
+		"Una historia aburrida": 0,
+	} {
+		s := models.SentimentAnalysis(sentence, Spanish)
+		if s.Language != Spanish || s.Score != class {
+			t.Errorf("Sentiment of sentence < %v > should be %v\n\treturned %v (%v)\n", sentence, class, s.Score, s.Language)
+		}
+	}
+}
+
+func TestTrainModelShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, Japanese, Documents{
+		{Text: "とても面白い映画でした", Class: 1},
+		{Text: "素晴らしい物語で面白い", Class: 1},
+		{Text: "とてもつまらない映画でした", Class: 0},
+		{Text: "退屈な物語でつまらない", Class: 0},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Training a Japanese model should not return an error!\n\t%v\n", err)
+	}
+
+	if models[Japanese].Predict("面白い") != 1 || models[Japanese].Predict("つまらない") != 0 {
+		t.Errorf("Japanese model should classify words without spaces between them\n")
+	}
+
+	bytes, err := json.Marshal(models)
+	if err != nil {
+		t.Fatalf("Marshalling a Japanese model should not return an error!\n\t%v\n", err)
+	}
+	restored, err := RestoreModels(bytes)
+	if err != nil {
+		t.Fatalf("Restoring a Japanese model should not return an error!\n\t%v\n", err)
+	}
+
+	if _, ok := restored[Japanese].Tokenizer.(*NGramTokenizer); !ok {
+		t.Errorf("Restored Japanese model should use the n-gram tokenizer\n\treturned %T\n", restored[Japanese].Tokenizer)
+	}
+	if restored[Japanese].Predict("面白い") != 1 {
+		t.Errorf("Restored Japanese model should classify the same as the original\n")
+	}
+}
+
+func TestNGramTokenizerShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	tokens := (&NGramTokenizer{N: 2}).Tokenize("面白い 映画 A")
+	expected := []string{"面白", "白い", "映画", "a"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Tokenizer should split words into bigrams\n\texpected %v\n\treturned %v\n", expected, tokens)
+	}
+}
+
+func TestOnlyWordsAndMarksShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	// the vowel signs in हिन्दी are
+	// combining marks
+	s := sanitize("हिन्दी, अच्छा!", OnlyWordsAndMarks)
+	if s != "हिन्दी अच्छा" {
+		t.Errorf("Sanitizer should keep combining marks but drop punctuation\n\treturned %v\n", s)
+	}
+}
@@ -0,0 +1,148 @@
+package sentiment
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cdipaolo/goml/base"
+	"github.com/cdipaolo/goml/text"
+)
+
+// Learn updates the model for the given
+// language with a single labeled document,
+// like a correction from a human reviewer.
+// It's safe to call while other goroutines
+// are running SentimentAnalysis; persist
+// the models afterwards to keep what was
+// learned.
+func (m Models) Learn(sentence string, class uint8, lang Language) error {
+	return m.LearnBatch([]Document{{Text: sentence, Class: class}}, lang)
+}
+
+// LearnBatch is the same as Learn but for
+// many documents at once, which only has
+// to take the model's lock once. Documents
+// with a star rating also update the star
+// rating model, if there is one.
+func (m Models) LearnBatch(docs []Document, lang Language) error {
+	model, ok := m[lang]
+	if !ok {
+		return fmt.Errorf("ERROR: no model for language < %v > to learn with", lang)
+	}
+
+	return model.LearnBatch(docs)
+}
+
+// LearnBatch updates the model with the
+// labeled documents. Either every document
+// is learned or, if any of them have a
+// class or rating out of range, none are.
+// If the model's vocabulary was pruned,
+// words outside of it are ignored.
+func (m *Model) LearnBatch(docs []Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, doc := range docs {
+		if int(doc.Class) >= len(m.Count) {
+			return &DocumentError{
+				Path: doc.Path,
+				Err:  fmt.Errorf("class %v is out of range, the model only has %v classes", doc.Class, len(m.Count)),
+			}
+		}
+		if doc.Rating > MaxRating {
+			return &DocumentError{
+				Path: doc.Path,
+				Err:  fmt.Errorf("rating %v is out of range, ratings go up to %v", doc.Rating, MaxRating),
+			}
+		}
+	}
+
+	if m.Pruned {
+		pruned := make([]Document, len(docs))
+		for i, doc := range docs {
+			doc.Text = m.inVocabulary(doc.Text)
+			pruned[i] = doc
+		}
+		docs = pruned
+	}
+
+	var rated []Document
+	for _, doc := range docs {
+		if doc.Rating != 0 && m.Ratings != nil {
+			rated = append(rated, doc)
+		}
+	}
+
+	if m.variant() == Complement && len(m.ClassTokens) == len(m.Count) {
+		for _, doc := range docs {
+			m.ClassTokens[doc.Class] += m.countTokens(doc.Text)
+		}
+	}
+
+	var before map[string][]uint64
+	if m.variant() == Bernoulli && len(m.frequencies) == len(m.Count) {
+		before = m.batchWords(docs)
+	}
+
+	tokenizer := m.Tokenizer
+	if m.variant() == Bernoulli {
+		m.Tokenizer = uniqueTokenizer{tokenizer}
+	}
+	err := learn(m.NaiveBayes, docs, func(doc Document) uint8 {
+		return doc.Class
+	})
+	m.Tokenizer = tokenizer
+	if before != nil {
+		m.updateFrequencies(before)
+	}
+	if m.Priors != nil {
+		// the learner recalculates the priors
+		// off of the class counts
+		copy(m.Probabilities, m.Priors)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = m.prepareVariant()
+	if err != nil {
+		return err
+	}
+
+	if len(rated) == 0 {
+		return nil
+	}
+
+	return learn(m.Ratings, rated, func(doc Document) uint8 {
+		return doc.Rating - 1
+	})
+}
+
+// learn runs the documents through the
+// Naive Bayes model's online learner,
+// blocking until they've been learned.
+// The caller must hold the model's lock.
+func learn(b *text.NaiveBayes, docs []Document, class func(Document) uint8) error {
+	stream := make(chan base.TextDatapoint, len(docs))
+	for _, doc := range docs {
+		stream <- base.TextDatapoint{
+			X: doc.Text,
+			Y: class(doc),
+		}
+	}
+	close(stream)
+
+	errors := make(chan error, len(docs)+1)
+
+	b.UpdateStream(stream)
+	if b.Output == nil {
+		b.Output = ioutil.Discard
+	}
+	b.OnlineLearn(errors)
+
+	// OnlineLearn closes the channel when
+	// it's done, so this is nil if there
+	// weren't any errors
+	return <-errors
+}
@@ -0,0 +1,74 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestLearnShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	// "splendiferous" hasn't been seen yet
+	before := models[English].DocumentCount
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			err := models.Learn("splendiferous splendiferous", 1, English)
+			if err != nil {
+				t.Errorf("Learning a document should not return an error!\n\t%v\n", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			models.SentimentAnalysis("a splendiferous film. Truly wonderful", English)
+			json.Marshal(models)
+		}()
+	}
+	wg.Wait()
+
+	if models[English].DocumentCount != before+4 {
+		t.Errorf("Model should have learned 4 more documents\n\treturned %v\n", models[English].DocumentCount-before)
+	}
+
+	if s := models.SentimentAnalysis("splendiferous", English); s.Score != 1 {
+		t.Errorf("Sentiment of a learned word should be positive\n\treturned %v\n", s.Score)
+	}
+}
+
+func TestLearnBatchShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	before := models[English].DocumentCount
+
+	err = models.LearnBatch([]Document{
+		{Text: "a fine document", Class: 1},
+		{Path: "bad", Text: "a bad class", Class: 2},
+	}, English)
+	if err == nil {
+		t.Errorf("Learning a document with a class out of range should return an error!\n")
+	}
+	if models[English].DocumentCount != before {
+		t.Errorf("A failed batch should not learn any documents\n\treturned %v more\n", models[English].DocumentCount-before)
+	}
+
+	err = models.Learn("no model for this", 1, French)
+	if err == nil {
+		t.Errorf("Learning for a language without a model should return an error!\n")
+	}
+}
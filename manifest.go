@@ -0,0 +1,362 @@
+package sentiment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonVersion is the version of the JSON
+// model format, which hasn't changed
+const jsonVersion = 1
+
+// Manifest describes where a model came
+// from, so persisted models can be audited
+// without loading them (see ReadManifests.)
+// Training fills in the language, when and
+// what it was trained off of, and how, and
+// persisting a model fills in the rest.
+type Manifest struct {
+	// Format and Version are the format the
+	// model was persisted in and its version
+	// (BinaryVersion for binary models)
+	Format  Format `json:"format,omitempty"`
+	Version int    `json:"version,omitempty"`
+
+	Language  Language   `json:"lang"`
+	TrainedAt *time.Time `json:"trained_at,omitempty"`
+
+	// Corpus describes what the model was
+	// trained off of (see
+	// TrainOptions.Description)
+	Corpus string `json:"corpus,omitempty"`
+
+	// Documents is the number of documents
+	// of each class the model has learned,
+	// including online, and Vocabulary the
+	// number of words it knows
+	Documents  []uint64 `json:"documents,omitempty"`
+	Vocabulary uint64   `json:"vocabulary"`
+
+	// Options are the options the model was
+	// trained with, if it was trained by
+	// this package
+	Options *ManifestOptions `json:"options,omitempty"`
+
+	// Evaluation is the last evaluation
+	// recorded with RecordEvaluation,
+	// without its misclassified or failed
+	// documents
+	Evaluation *Evaluation `json:"evaluation,omitempty"`
+
+	// Hash is the hash of the model's
+	// content when it was persisted in the
+	// Format (see Model.Hash)
+	Hash string `json:"hash,omitempty"`
+}
+
+// ManifestOptions are the TrainOptions a
+// model was trained with that can be
+// recorded. Vocabulary and Stopwords are
+// the number of words in each list, and
+// Tokenizer the type of the tokenizer.
+type ManifestOptions struct {
+	Classes         int       `json:"classes"`
+	Strict          bool      `json:"strict,omitempty"`
+	MaxErrors       int       `json:"max_errors,omitempty"`
+	Tokenizer       string    `json:"tokenizer,omitempty"`
+	Vocabulary      int       `json:"vocabulary,omitempty"`
+	MinDocFrequency int       `json:"min_doc_frequency,omitempty"`
+	MaxVocabulary   int       `json:"max_vocabulary,omitempty"`
+	Stopwords       int       `json:"stopwords,omitempty"`
+	Balance         Balance   `json:"balance,omitempty"`
+	Priors          []float64 `json:"priors,omitempty"`
+	Variant         Variant   `json:"variant,omitempty"`
+	Smoothing       float64   `json:"smoothing,omitempty"`
+}
+
+// newManifest returns the manifest of a
+// model of the language just trained off of
+// the corpus with the given options
+func newManifest(lang Language, corpus Corpus, classes int, opts *TrainOptions) *Manifest {
+	now := time.Now().UTC()
+	manifest := &Manifest{
+		Language:  lang,
+		TrainedAt: &now,
+		Corpus:    describeCorpus(corpus),
+		Options:   &ManifestOptions{Classes: classes},
+	}
+	if opts == nil {
+		return manifest
+	}
+
+	if opts.Description != "" {
+		manifest.Corpus = opts.Description
+	}
+	if opts.Tokenizer != nil {
+		manifest.Options.Tokenizer = fmt.Sprintf("%T", opts.Tokenizer)
+	}
+	manifest.Options.Strict = opts.Strict
+	manifest.Options.MaxErrors = opts.MaxErrors
+	manifest.Options.Vocabulary = len(opts.Vocabulary)
+	manifest.Options.MinDocFrequency = opts.MinDocFrequency
+	manifest.Options.MaxVocabulary = opts.MaxVocabulary
+	manifest.Options.Stopwords = len(opts.Stopwords)
+	manifest.Options.Balance = opts.Balance
+	manifest.Options.Priors = opts.priors()
+	manifest.Options.Variant = opts.Variant
+	manifest.Options.Smoothing = opts.Smoothing
+
+	return manifest
+}
+
+// describeCorpus returns a short description
+// of the corpus for a manifest
+func describeCorpus(corpus Corpus) string {
+	switch c := corpus.(type) {
+	case *DirCorpus:
+		classes := make([]int, 0, len(c.Classes))
+		for class := range c.Classes {
+			classes = append(classes, int(class))
+		}
+		sort.Ints(classes)
+
+		dirs := make([]string, len(classes))
+		for i, class := range classes {
+			dirs[i] = fmt.Sprintf("%v: %v", class, c.Classes[uint8(class)])
+		}
+		return fmt.Sprintf("class directories (%v)", strings.Join(dirs, ", "))
+	case *LineCorpus:
+		return fmt.Sprintf("labeled lines in %v", c.Name)
+	case *TextCorpus:
+		return fmt.Sprintf("unlabeled lines in %v", c.Name)
+	case *CSVCorpus:
+		return fmt.Sprintf("rows of %v", c.Name)
+	case *JSONLCorpus:
+		return fmt.Sprintf("JSON lines in %v", c.Name)
+	case Documents:
+		return fmt.Sprintf("%v documents in memory", len(c))
+	case multiCorpus:
+		parts := make([]string, len(c))
+		for i := range c {
+			parts[i] = describeCorpus(c[i])
+		}
+		return strings.Join(parts, " + ")
+	case *cleanCorpus:
+		return fmt.Sprintf("cleaned %v", describeCorpus(c.corpus))
+	case *weakCorpus:
+		return fmt.Sprintf("weakly labeled %v (confidence %v)", describeCorpus(c.labels.corpus), c.confidence)
+	default:
+		return fmt.Sprintf("%T", corpus)
+	}
+}
+
+// RecordEvaluation records the evaluation
+// in the model's manifest, so it's
+// persisted with the model
+func (m *Model) RecordEvaluation(e *Evaluation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Manifest == nil {
+		m.Manifest = &Manifest{}
+	}
+	if e == nil {
+		m.Manifest.Evaluation = nil
+		return
+	}
+
+	evaluation := *e
+	evaluation.Misclassified = nil
+	evaluation.Failed = nil
+	m.Manifest.Evaluation = &evaluation
+}
+
+// Hash returns the SHA-256 hash of the
+// model's content as it's persisted in the
+// format: its classifiers and everything it
+// was trained with, but not its manifest.
+// It can be checked against the Hash of a
+// manifest with the same Format.
+func (m *Model) Hash(format Format) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON, "":
+		data, err = m.marshalContent()
+	case FormatBinary:
+		data, err = m.marshalBody()
+	default:
+		return "", fmt.Errorf("ERROR: unknown model format < %v >", format)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return contentHash(data), nil
+}
+
+// contentHash returns the hash of a
+// model's encoded content
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// manifest returns the manifest to persist
+// the model with in the format, given the
+// hash of its encoded content. The caller
+// must hold the model's lock.
+func (m *Model) manifest(lang Language, format Format, hash string) *Manifest {
+	manifest := &Manifest{}
+	if m.Manifest != nil {
+		*manifest = *m.Manifest
+	}
+
+	manifest.Format = format
+	manifest.Version = jsonVersion
+	if format == FormatBinary {
+		manifest.Version = BinaryVersion
+	}
+	if lang != NoLanguage {
+		manifest.Language = lang
+	}
+	manifest.Documents = append([]uint64(nil), m.Count...)
+	manifest.Vocabulary = m.DictCount
+	manifest.Hash = hash
+
+	return manifest
+}
+
+// ReadManifests reads the manifest of every
+// model written by Encode (or PersistToFile
+// or PersistToFileBinary) from r, skipping
+// past the models themselves. Models
+// persisted before manifests were added
+// have a nil manifest.
+func ReadManifests(r io.Reader) (map[Language]*Manifest, error) {
+	br, isBinary, err := modelReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests map[Language]*Manifest
+	if isBinary {
+		manifests, err = readBinaryManifests(br)
+	} else {
+		manifests, err = readJSONManifests(br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading model manifests!\n\t%w\n", err)
+	}
+
+	return manifests, nil
+}
+
+// readJSONManifests reads the manifests
+// of JSON models, skipping the rest of
+// each model token by token so its words
+// are never held in memory
+func readJSONManifests(r io.Reader) (map[Language]*Manifest, error) {
+	dec := json.NewDecoder(r)
+	err := expectDelim(dec, '{')
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make(map[Language]*Manifest)
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		lang := Language(key.(string))
+
+		// Models.MarshalJSON writes nil
+		// models as null, which have no
+		// manifest to read
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			continue
+		}
+		if token != json.Delim('{') {
+			return nil, fmt.Errorf("model for language < %v >: expected {, found %v", lang, token)
+		}
+
+		var manifest *Manifest
+		for dec.More() {
+			field, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			if field == "manifest" {
+				err = dec.Decode(&manifest)
+			} else {
+				err = skipValue(dec)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = expectDelim(dec, '}')
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest != nil && manifest.Language == NoLanguage {
+			manifest.Language = lang
+		}
+		manifests[lang] = manifest
+	}
+
+	return manifests, expectDelim(dec, '}')
+}
+
+// skipValue reads past the next JSON value
+// a token at a time
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// expectDelim reads the next JSON token,
+// which should be the delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, found %v", delim, token)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package sentiment
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+func TestManifestShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+
+	e, err := models.Evaluate(&DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs}, English, 1)
+	if err != nil {
+		t.Fatalf("Evaluating a model should not return an error!\n\t%v\n", err)
+	}
+	models[English].RecordEvaluation(e)
+
+	hashes := make(map[Format]string)
+	for _, format := range []Format{FormatJSON, FormatBinary} {
+		hashes[format], err = models[English].Hash(format)
+		if err != nil {
+			t.Fatalf("Hashing a model as %v should not return an error!\n\t%v\n", format, err)
+		}
+	}
+	if hashes[FormatJSON] == hashes[FormatBinary] {
+		t.Errorf("A model's hash should depend on its format\n")
+	}
+	if _, err := models[English].Hash("yaml"); err == nil {
+		t.Errorf("Hashing a model in an unknown format should return an error\n")
+	}
+
+	for _, opts := range []*EncodeOptions{
+		nil,
+		{Format: FormatBinary},
+		{Format: FormatJSON, Gzip: true},
+		{Format: FormatBinary, Gzip: true},
+	} {
+		var buf bytes.Buffer
+		err := models.Encode(&buf, opts)
+		if err != nil {
+			t.Fatalf("Encoding models with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+
+		manifests, err := ReadManifests(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+		if err != nil {
+			t.Fatalf("Reading manifests of models encoded with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+		if len(manifests) != 2 {
+			t.Fatalf("There should be a manifest for each model\n\treturned %v\n", manifests)
+		}
+
+		manifest := manifests[English]
+		if manifest == nil {
+			t.Fatalf("Models encoded with %+v should have an English manifest\n", opts)
+		}
+
+		format, version := FormatJSON, jsonVersion
+		if opts != nil && opts.Format == FormatBinary {
+			format, version = FormatBinary, BinaryVersion
+		}
+		if manifest.Format != format || manifest.Version != version || manifest.Language != English {
+			t.Errorf("Manifest should be of %v version %v in English\n\treturned %+v\n", format, version, manifest)
+		}
+		if manifest.TrainedAt == nil || manifest.Corpus != "class directories (0: neg, 1: pos)" {
+			t.Errorf("Manifest should say when and what the model was trained off of\n\treturned %+v\n", manifest)
+		}
+		if len(manifest.Documents) != 2 || manifest.Documents[0] != models[English].Count[0] || manifest.Vocabulary != models[English].DictCount {
+			t.Errorf("Manifest should count the model's documents and words\n\treturned %+v\n", manifest)
+		}
+		if manifest.Options == nil || manifest.Options.Classes != 2 || manifest.Options.Variant != Complement || manifest.Options.MaxVocabulary != 50 {
+			t.Errorf("Manifest should have the training options\n\treturned %+v\n", manifest.Options)
+		}
+		if manifest.Evaluation == nil || manifest.Evaluation.Accuracy != e.Accuracy || len(manifest.Evaluation.Misclassified) != 0 {
+			t.Errorf("Manifest should have the recorded evaluation, without misclassified documents\n\treturned %+v\n", manifest.Evaluation)
+		}
+		hash := hashes[format]
+		if manifest.Hash != hash {
+			t.Errorf("Manifest should have the model's hash\n\texpected %v\n\treturned %v\n", hash, manifest.Hash)
+		}
+
+		if manifests[Spanish] == nil || manifests[Spanish].Options.Balance != Oversample {
+			t.Errorf("Models encoded with %+v should have a Spanish manifest\n", opts)
+		}
+
+		decoded, err := Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Decoding models encoded with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+		if again, _ := decoded[English].Hash(format); again != hash {
+			t.Errorf("Models encoded with %+v should decode to the same hash\n\texpected %v\n\treturned %v\n", opts, hash, again)
+		}
+	}
+
+	err = models.Learn("something new entirely", 1, English)
+	if err != nil {
+		t.Fatalf("Learning should not return an error!\n\t%v\n", err)
+	}
+	if learned, _ := models[English].Hash(FormatJSON); learned == hashes[FormatJSON] {
+		t.Errorf("Learning should change the model's hash\n")
+	}
+}
+
+func TestManifestShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	// the built in model predates manifests
+	data, err := Asset("model.json")
+	if err != nil {
+		t.Fatalf("Reading the built in model should not return an error!\n\t%v\n", err)
+	}
+
+	manifests, err := ReadManifests(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Reading manifests should not return an error!\n\t%v\n", err)
+	}
+	if m, ok := manifests[English]; !ok || m != nil {
+		t.Errorf("A model without a manifest should have a nil manifest\n\treturned %v, %v\n", m, ok)
+	}
+}
+
+func TestManifestShouldPass3(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+	models[Spanish] = nil
+
+	data, err := models.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Marshalling models should not return an error!\n\t%v\n", err)
+	}
+
+	manifests, err := ReadManifests(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Reading manifests with a null model should not return an error!\n\t%v\n", err)
+	}
+	if _, ok := manifests[Spanish]; ok || manifests[English] == nil {
+		t.Errorf("A null model should be skipped\n\treturned %v\n", manifests)
+	}
+}
+
+func TestManifestShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	data, err := binaryModels(t).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Encoding binary models should not return an error!\n\t%v\n", err)
+	}
+
+	future := append([]byte{}, data...)
+	future[len(binaryMagic)] = BinaryVersion + 1
+
+	for _, data := range [][]byte{
+		data[:len(binaryMagic)+4],
+		data[:len(data)-1],
+		future,
+		[]byte(`{"en": {"manifest": "nope"}}`),
+		[]byte(`{"en": [1, 2]}`),
+		[]byte(`{"en": {"words": {}`),
+		nil,
+	} {
+		if _, err := ReadManifests(bytes.NewReader(data)); err == nil {
+			t.Errorf("Reading manifests from %v bytes of malformed models should return an error\n", len(data))
+		}
+	}
+}
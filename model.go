@@ -1,6 +1,8 @@
 package sentiment
 
 import (
+	"sync"
+
 	"github.com/cdipaolo/goml/text"
 )
 
@@ -48,16 +50,117 @@ const (
 
 // Models holds a map from language keys
 // to sentiment classifiers.
-type Models map[Language]*text.NaiveBayes
+type Models map[Language]*Model
+
+// Model is the sentiment classifier for
+// a single language. It can optionally
+// carry a second, finer grained classifier
+// which predicts star ratings.
+//
+// A Model is safe for concurrent use as
+// long as it's only updated through its
+// Learn methods.
+type Model struct {
+	*text.NaiveBayes
+
+	// mu guards the classifiers, which
+	// online learning writes to
+	mu sync.RWMutex
+
+	// sanitize is the sanitization function
+	// the model was trained with (nil means
+	// base.OnlyWords)
+	sanitize func(rune) bool
+
+	// Ratings predicts the star rating
+	// (from 1 to MaxRating) of a document,
+	// where class i is a rating of i+1.
+	// It's nil unless the model was
+	// trained with TrainRatings.
+	Ratings *text.NaiveBayes `json:"ratings,omitempty"`
+
+	// Pruned is whether the model's
+	// vocabulary was pruned when it was
+	// trained, and Vocabulary the sorted
+	// list of words it was pruned down to.
+	// Learning online ignores any other word.
+	// Vocabulary isn't persisted: it's the
+	// words the model knows, so restoring a
+	// pruned model works it out again.
+	Pruned     bool     `json:"pruned,omitempty"`
+	Vocabulary []string `json:"-"`
+
+	// NeutralThreshold, if set, makes a two
+	// class model call a document Neutral when
+	// it's less than this sure (between 0.5
+	// and 1) of either class. Three class
+	// models predict Neutral on their own
+	// and ignore it.
+	NeutralThreshold float64 `json:"neutral_threshold,omitempty"`
+
+	// Balance is the balancing strategy the
+	// model was trained with, if any
+	Balance Balance `json:"balance,omitempty"`
+
+	// Priors are the class priors the model
+	// was trained with, or nil if it uses
+	// the share of documents it learned of
+	// each class. Online learning keeps them.
+	// Complement models ignore them.
+	Priors []float64 `json:"priors,omitempty"`
+
+	// Variant is the Naive Bayes event model
+	// the model was trained with, and
+	// Smoothing its smoothing constant (zero
+	// means 1.) The star rating model is
+	// always Multinomial with the default
+	// smoothing.
+	Variant   Variant `json:"variant,omitempty"`
+	Smoothing float64 `json:"smoothing,omitempty"`
+
+	// ClassTokens is the number of words
+	// a Complement model has learned of
+	// each class
+	ClassTokens []uint64 `json:"class_tokens,omitempty"`
+
+	// Manifest describes where the model
+	// came from. It's set when the model is
+	// trained and persisted with it.
+	Manifest *Manifest `json:"manifest,omitempty"`
+
+	// absent is the log probability of a
+	// document of each class not using any
+	// word a Bernoulli model knows
+	absent []float64
+
+	// frequencies holds, for each class, how
+	// many of the words a Bernoulli model
+	// knows are in each number of documents
+	// of the class, so it can work out absent
+	// again after learning without going
+	// through every word
+	frequencies []map[uint64]uint64
+
+	// vocabulary is a set of the words in
+	// Vocabulary, built the first time
+	// it's needed
+	vocabulary map[string]bool
+}
 
 // Score holds the score of a
 // singular word (differs from
 // SentenceScore only in param
 // names and JSON marshaling, not
 // actualy types)
+//
+// Score is the class the model predicted
+// and Class the sentiment, which is also
+// Neutral when a two class model isn't
+// sure enough (see NeutralThreshold.)
 type Score struct {
 	Word  string `json:"word"`
 	Score uint8  `json:"score"`
+	Class Class  `json:"class"`
 }
 
 // SentenceScore holds the score
@@ -66,6 +169,7 @@ type Score struct {
 type SentenceScore struct {
 	Sentence string `json:"sentence"`
 	Score    uint8  `json:"score"`
+	Class    Class  `json:"class"`
 }
 
 // Analysis returns the analysis
@@ -74,9 +178,18 @@ type SentenceScore struct {
 // sentiment, and individual word
 // sentiment, along with the language
 // code
+//
+// If the model has a star rating classifier
+// Stars holds the most likely rating of the
+// document and Rating the expected rating
+// (a continuous score on [1, MaxRating].)
+// Both are zero otherwise.
 type Analysis struct {
 	Language  Language        `json:"lang"`
 	Words     []Score         `json:"words"`
 	Sentences []SentenceScore `json:"sentences,omitempty"`
 	Score     uint8           `json:"score"`
+	Class     Class           `json:"class"`
+	Stars     uint8           `json:"stars,omitempty"`
+	Rating    float64         `json:"rating,omitempty"`
 }
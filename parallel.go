@@ -0,0 +1,143 @@
+package sentiment
+
+import (
+	"errors"
+	"sync"
+)
+
+// ConcurrentCorpus is a Corpus that can read
+// its documents with a pool of workers.
+// WalkConcurrent calls fn with the same
+// documents, in the same order, as Walk
+// does; only the reading happens
+// concurrently.
+type ConcurrentCorpus interface {
+	Corpus
+	WalkConcurrent(workers int, fn WalkFunc) error
+}
+
+// errStopped stops a walk that's being fed
+// to workers once its consumer has quit
+var errStopped = errors.New("walk stopped")
+
+// walkOrdered walks the corpus, running every
+// document through prepare on a pool of
+// workers, and calls fn with the prepared
+// documents in the order the corpus walked
+// them, so the result is the same no matter
+// how many workers there are. If prepare
+// fails, fn gets its error alongside the
+// document. With one worker (or less) it's
+// just a plain walk.
+//
+// Corpora that implement ConcurrentCorpus
+// are read with the same number of workers.
+func walkOrdered(corpus Corpus, workers int, prepare func(Document) (Document, error), fn WalkFunc) error {
+	if workers <= 1 {
+		return corpus.Walk(func(doc Document, err error) error {
+			if err == nil {
+				doc, err = prepare(doc)
+			}
+			return fn(doc, err)
+		})
+	}
+
+	type item struct {
+		seq int
+		doc Document
+		err error
+	}
+
+	jobs := make(chan item, workers)
+	results := make(chan item, workers)
+	stop := make(chan struct{})
+	walked := make(chan error, 1)
+
+	// inflight bounds the number of documents
+	// read but not yet passed to fn, so a slow
+	// document can't make the rest pile up
+	// in memory
+	inflight := make(chan struct{}, 4*workers)
+
+	go func() {
+		var seq int
+		walk := func(doc Document, err error) error {
+			select {
+			case inflight <- struct{}{}:
+			case <-stop:
+				return errStopped
+			}
+
+			select {
+			case jobs <- item{seq: seq, doc: doc, err: err}:
+				seq++
+				return nil
+			case <-stop:
+				return errStopped
+			}
+		}
+
+		var err error
+		if c, ok := corpus.(ConcurrentCorpus); ok {
+			err = c.WalkConcurrent(workers, walk)
+		} else {
+			err = corpus.Walk(walk)
+		}
+
+		close(jobs)
+		walked <- err
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range jobs {
+				if it.err == nil {
+					it.doc, it.err = prepare(it.doc)
+				}
+				results <- it
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// put the results back in order, and
+	// keep draining them after fn fails so
+	// the workers can finish
+	pending := make(map[int]item)
+	var next int
+	var err error
+	for it := range results {
+		if err != nil {
+			continue
+		}
+
+		pending[it.seq] = it
+		for err == nil {
+			it, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-inflight
+
+			err = fn(it.doc, it.err)
+			if err != nil {
+				close(stop)
+			}
+		}
+	}
+
+	walkErr := <-walked
+	if err == nil && !errors.Is(walkErr, errStopped) {
+		err = walkErr
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package sentiment
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPerm is the permissions persisted
+// models are written with by default
+const DefaultPerm os.FileMode = 0644
+
+// PersistOptions configures how models are
+// persisted to a file. A nil (or zero)
+// PersistOptions writes uncompressed JSON
+// with DefaultPerm.
+type PersistOptions struct {
+	EncodeOptions
+
+	// Perm is the permissions of the file,
+	// which are set exactly rather than
+	// being masked by the umask
+	Perm os.FileMode
+}
+
+// Persist writes the models to the file at
+// path. They're written to a temporary file
+// in the same directory first, which is
+// then renamed over path, so readers never
+// see a half written file and models
+// persisted to the same path at once (by
+// concurrent retraining jobs, say) don't
+// clobber each other: whichever is renamed
+// last wins. opts can be nil to write JSON
+// with DefaultPerm.
+func (m Models) Persist(path string, opts *PersistOptions) error {
+	if path == "" {
+		return fmt.Errorf("ERROR: you just tried to persist your model to a file with no path!! That's a no-no. Try it with a valid filepath")
+	}
+
+	perm := DefaultPerm
+	var encode *EncodeOptions
+	if opts != nil {
+		encode = &opts.EncodeOptions
+		if opts.Perm != 0 {
+			perm = opts.Perm.Perm()
+		}
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("Error creating a temporary file to persist models to!\n\t%w\n", err)
+	}
+
+	err = writeModels(f, m, encode, perm)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("Error persisting models to < %v >!\n\t%w\n", path, err)
+	}
+
+	err = f.Close()
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("Error persisting models to < %v >!\n\t%w\n", path, err)
+	}
+
+	return nil
+}
+
+// writeModels encodes the models to the
+// temporary file f, gives it its
+// permissions, and syncs it to disk so it
+// can be renamed into place
+func writeModels(f *os.File, m Models, opts *EncodeOptions, perm os.FileMode) error {
+	w := bufio.NewWriter(f)
+	err := m.Encode(w, opts)
+	if err != nil {
+		return err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = f.Chmod(perm)
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
@@ -0,0 +1,135 @@
+package sentiment
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPersistShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+	dir := t.TempDir()
+
+	for _, opts := range []*PersistOptions{
+		nil,
+		{EncodeOptions: EncodeOptions{Format: FormatBinary}},
+		{EncodeOptions: EncodeOptions{Gzip: true}, Perm: 0600},
+	} {
+		path := filepath.Join(dir, "model")
+		err := models.Persist(path, opts)
+		if err != nil {
+			t.Fatalf("Persisting models with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Persisted models should be on disk!\n\t%v\n", err)
+		}
+		perm := DefaultPerm
+		if opts != nil && opts.Perm != 0 {
+			perm = opts.Perm
+		}
+		if info.Mode().Perm() != perm {
+			t.Errorf("Models persisted with %+v should have permissions %v\n\treturned %v\n", opts, perm, info.Mode().Perm())
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Opening persisted models should not return an error!\n\t%v\n", err)
+		}
+		decoded, err := Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Decoding models persisted with %+v should not return an error!\n\t%v\n", opts, err)
+		}
+		if decoded[English].Predict("I loved this movie") != models[English].Predict("I loved this movie") {
+			t.Errorf("Models persisted with %+v should predict the same\n", opts)
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Reading the directory should not return an error!\n\t%v\n", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Persisting should only leave the models behind\n\treturned %v files\n", len(files))
+	}
+}
+
+func TestPersistShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// retraining jobs persisting at once
+	// should leave one whole model behind
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = PersistToFile(models, path)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Persisting models concurrently (%v) should not return an error!\n\t%v\n", i, err)
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading persisted models should not return an error!\n\t%v\n", err)
+	}
+	if _, err := RestoreModels(data); err != nil {
+		t.Errorf("Models persisted concurrently should restore\n\treturned %v\n", err)
+	}
+}
+
+func TestPersistShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	models := binaryModels(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.json")
+
+	err := ioutil.WriteFile(path, []byte("keep me"), 0644)
+	if err != nil {
+		t.Fatalf("Writing a file should not return an error!\n\t%v\n", err)
+	}
+
+	// a failed write leaves the old file as
+	// it was, without any temporary files
+	err = models.Persist(path, &PersistOptions{EncodeOptions: EncodeOptions{Format: "yaml"}})
+	if err == nil {
+		t.Errorf("Persisting models in an unknown format should return an error\n")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil || string(data) != "keep me" {
+		t.Errorf("A failed persist should leave the old file alone\n\treturned %q, %v\n", data, err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Errorf("A failed persist should clean up after itself\n\treturned %v files, %v\n", len(files), err)
+	}
+
+	for _, path := range []string{"", filepath.Join(dir, "missing", "model.json")} {
+		if err := PersistToFile(models, path); err == nil {
+			t.Errorf("Persisting models to < %v > should return an error\n", path)
+		}
+	}
+
+	if err := PersistToFileBinary(models, fmt.Sprintf("%v/", dir)); err == nil {
+		t.Errorf("Persisting models over a directory should return an error\n")
+	}
+}
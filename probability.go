@@ -0,0 +1,136 @@
+package sentiment
+
+import (
+	"math"
+	"strings"
+
+	"github.com/cdipaolo/goml/base"
+	"github.com/cdipaolo/goml/text"
+)
+
+// sanitize strips the runes the model's
+// sanitization function says to remove,
+// the same way text.NaiveBayes does
+// internally
+func sanitize(sentence string, remove func(rune) bool) string {
+	return strings.Map(func(r rune) rune {
+		if remove(r) {
+			return -1
+		}
+		return r
+	}, sentence)
+}
+
+// sanitizer returns the model's sanitization
+// function, which is base.OnlyWords unless
+// the model was trained (or updated) with
+// another one
+func (m *Model) sanitizer() func(rune) bool {
+	if m.sanitize == nil {
+		return base.OnlyWords
+	}
+
+	return m.sanitize
+}
+
+// UpdateSanitize updates the sanitization
+// function of the model (and its star
+// rating model.) Models only remember their
+// sanitization function in memory, so a
+// model trained with anything other than
+// the default needs it set again after
+// being restored.
+func (m *Model) UpdateSanitize(sanitize func(rune) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sanitize = sanitize
+	m.NaiveBayes.UpdateSanitize(sanitize)
+	if m.Ratings != nil {
+		m.Ratings.UpdateSanitize(sanitize)
+	}
+}
+
+// probabilities is the probabilities func
+// below for the model's main classifier,
+// scored with the model's variant. The
+// caller must hold the model's lock.
+func (m *Model) probabilities(sentence string) (uint8, []float64) {
+	return normalize(m.logScores(sentence))
+}
+
+// logScores returns
+//
+//	log(P(y = c)) + Σ log(P(x|y = c))
+//
+// for every class c, calculated the same
+// way text.NaiveBayes.Predict does (but with
+// smoothing constant alpha rather than 1),
+// so the argmax of the scores is the
+// predicted class
+func logScores(b *text.NaiveBayes, remove func(rune) bool, alpha float64, sentence string) []float64 {
+	sums := make([]float64, len(b.Count))
+
+	words := b.Tokenizer.Tokenize(sanitize(sentence, remove))
+	for _, word := range words {
+		w, ok := b.Words.Get(word)
+		if !ok {
+			continue
+		}
+
+		for i := range sums {
+			sums[i] += math.Log((float64(w.Count[i]) + alpha) / (float64(w.Seen) + alpha*float64(b.DictCount)))
+		}
+	}
+
+	for i := range sums {
+		sums[i] += math.Log(b.Probabilities[i])
+	}
+
+	return sums
+}
+
+// probabilities returns the probability
+// that the sentence is of each class, along
+// with the most probable class. Unlike
+// text.NaiveBayes.Probability this works in
+// log space, so it won't underflow on
+// long documents.
+func probabilities(b *text.NaiveBayes, remove func(rune) bool, sentence string) (uint8, []float64) {
+	return normalize(logScores(b, remove, 1, sentence))
+}
+
+// normalize turns log scores into the
+// probability of each class, returning them
+// along with the most probable class. The
+// scores are overwritten.
+func normalize(sums []float64) (uint8, []float64) {
+	var maxI int
+	for i := range sums {
+		if sums[i] > sums[maxI] {
+			maxI = i
+		}
+	}
+
+	if math.IsInf(sums[maxI], -1) {
+		// the model hasn't seen anything
+		for i := range sums {
+			sums[i] = 1 / float64(len(sums))
+		}
+		return uint8(maxI), sums
+	}
+
+	// log-sum-exp, shifted by the max so
+	// the exponentials can't underflow
+	max := sums[maxI]
+	var denom float64
+	for i := range sums {
+		sums[i] = math.Exp(sums[i] - max)
+		denom += sums[i]
+	}
+	for i := range sums {
+		sums[i] /= denom
+	}
+
+	return uint8(maxI), sums
+}
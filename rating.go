@@ -0,0 +1,105 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// TrainRatings trains the model's star
+// rating classifier off of the star
+// ratings of the documents in the corpus,
+// replacing any it had before. Documents
+// without a rating are skipped. opts can
+// be nil to train with the defaults, and
+// its Sanitize and Tokenizer are ignored in
+// favor of the model's own. If the model's
+// vocabulary was pruned, so is the star
+// rating model's.
+func (m *Model) TrainRatings(corpus Corpus, opts *TrainOptions) (*TrainResult, error) {
+	return m.TrainRatingsContext(context.Background(), corpus, opts)
+}
+
+// TrainRatingsContext is the same as
+// TrainRatings but stops early and returns
+// ctx.Err() if ctx is cancelled, leaving the
+// model's star rating classifier as it was
+func (m *Model) TrainRatingsContext(ctx context.Context, corpus Corpus, opts *TrainOptions) (*TrainResult, error) {
+	// the star rating model has to read
+	// documents the same way the main
+	// model does
+	ratingOpts := TrainOptions{}
+	if opts != nil {
+		ratingOpts = *opts
+	}
+	m.mu.RLock()
+	ratingOpts.Sanitize = m.sanitizer()
+	ratingOpts.Tokenizer = m.Tokenizer
+	if m.Pruned {
+		ratingOpts.Vocabulary = m.Vocabulary
+	}
+	// priors, variants and smoothing are
+	// for the main model, not ratings
+	ratingOpts.Priors = nil
+	ratingOpts.Variant = ""
+	ratingOpts.Smoothing = 0
+	m.mu.RUnlock()
+
+	ratings, result, err := trainNaiveBayes(ctx, corpus, MaxRating, func(doc Document) (uint8, bool) {
+		if doc.Rating == 0 {
+			return 0, false
+		}
+		return doc.Rating - 1, true
+	}, &ratingOpts)
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return result, ctx.Err()
+	}
+	if err != nil {
+		return result, fmt.Errorf("Error training star rating model!\n\t%w\n", err)
+	}
+	if ratings.DocumentCount == 0 {
+		return result, fmt.Errorf("Error training star rating model!\n\tNo documents in the corpus have a star rating\n")
+	}
+	if ratingOpts.prunes() {
+		ratings, _, err = pruneVocabulary(ratings, &ratingOpts)
+		if err != nil {
+			return result, fmt.Errorf("Error training star rating model!\n\t%w\n", err)
+		}
+	}
+
+	m.mu.Lock()
+	m.Ratings = ratings
+	m.mu.Unlock()
+
+	return result, nil
+}
+
+// Rating predicts the star rating of the
+// sentence, returning both the most likely
+// rating and the expected rating, which is
+// a continuous score on [1, MaxRating]. It
+// returns zeros if the model doesn't have
+// a star rating classifier.
+func (m *Model) Rating(sentence string) (uint8, float64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.rating(sentence)
+}
+
+// rating is Rating for callers that
+// already hold the model's lock
+func (m *Model) rating(sentence string) (uint8, float64) {
+	if m.Ratings == nil {
+		return 0, 0
+	}
+
+	class, probs := probabilities(m.Ratings, m.sanitizer(), sentence)
+
+	var expected float64
+	for i, p := range probs {
+		expected += p * float64(i+1)
+	}
+
+	return class + 1, expected
+}
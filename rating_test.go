@@ -0,0 +1,68 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRatingFromNameShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	for name, rating := range map[string]uint8{
+		"pos/10000_7.txt": 7,
+		"neg/0_1.txt":     1,
+		"10_10.txt":       10,
+		"10_11.txt":       0,
+		"10_0.txt":        0,
+		"review.txt":      0,
+		"some_review.txt": 0,
+	} {
+		if r := ratingFromName(name); r != rating {
+			t.Errorf("Rating of < %v > should be %v\n\treturned %v\n", name, rating, r)
+		}
+	}
+}
+
+func TestTrainRatingsShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, nil)
+	if err != nil {
+		t.Fatalf("Training off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	analysis := models.SentimentAnalysis("a wonderful and delightful film", English)
+	if analysis.Stars != 0 || analysis.Rating != 0 {
+		t.Errorf("Analysis without a rating model should not have a rating\n\treturned %v\n", analysis)
+	}
+
+	_, err = models[English].TrainRatings(&DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs}, nil)
+	if err != nil {
+		t.Fatalf("Training ratings off of an in-memory corpus should not return an error!\n\t%v\n", err)
+	}
+
+	positive := models.SentimentAnalysis("a wonderful and delightful film", English)
+	if positive.Stars < 8 || positive.Rating < 5.5 || positive.Rating > MaxRating {
+		t.Errorf("Rating of a positive sentence should be high\n\treturned %v (%v)\n", positive.Stars, positive.Rating)
+	}
+
+	analysis = models.SentimentAnalysis("a terrible and boring film", English)
+	if analysis.Stars > 4 || analysis.Rating > 5.5 || analysis.Rating < 1 {
+		t.Errorf("Rating of a negative sentence should be low\n\treturned %v (%v)\n", analysis.Stars, analysis.Rating)
+	}
+
+	bytes, err := json.Marshal(models)
+	if err != nil {
+		t.Fatalf("Marshaling a model with ratings should not return an error!\n\t%v\n", err)
+	}
+
+	restored, err := RestoreModels(bytes)
+	if err != nil {
+		t.Fatalf("Restoring a model with ratings should not return an error!\n\t%v\n", err)
+	}
+
+	if s, r := restored[English].Rating("a wonderful and delightful film"); s != positive.Stars || r != positive.Rating {
+		t.Errorf("Restored rating model should predict the same rating\n\treturned %v (%v)\n", s, r)
+	}
+}
@@ -0,0 +1,290 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+// SelfTrainOptions configures self-training.
+// A nil (or zero) SelfTrainOptions self-trains
+// with the defaults.
+type SelfTrainOptions struct {
+	// Rounds is the number of times the
+	// model is retrained on its own
+	// predictions. It defaults to 3.
+	Rounds int
+
+	// Confidence is the lowest probability
+	// (from 0 to 1) the model has to give a
+	// document's class for the document to
+	// be learned as that class. It defaults
+	// to 0.9.
+	Confidence float64
+
+	// Test, if set, is a labeled corpus held
+	// out from self-training which the model
+	// is evaluated against before and after
+	// every round
+	Test Corpus
+
+	// Logger, if set, is used to log each
+	// round
+	Logger *log.Logger
+}
+
+// SelfTrainResult holds how predictions
+// moved while self-training
+type SelfTrainResult struct {
+	// Documents is the number of unlabeled
+	// documents scored each round
+	Documents int `json:"documents"`
+
+	// Baseline is the starting model's
+	// evaluation against the Test corpus, if
+	// there is one
+	Baseline *Evaluation `json:"baseline,omitempty"`
+
+	Rounds []*SelfTrainRound `json:"rounds"`
+}
+
+// SelfTrainRound holds what happened in a
+// single round of self-training
+type SelfTrainRound struct {
+	Round int `json:"round"`
+
+	// Learned is the number of documents
+	// the model was confident enough about
+	// to learn, with the number of each
+	// class in Classes
+	Learned int   `json:"learned"`
+	Classes []int `json:"classes"`
+
+	// Predicted is the number of documents
+	// predicted to be of each class by the
+	// model going into the round, and
+	// Changed the number whose predicted
+	// class changed since the last round
+	Predicted []int `json:"predicted"`
+	Changed   int   `json:"changed"`
+
+	// Evaluation is the retrained model's
+	// evaluation against the Test corpus, if
+	// there is one
+	Evaluation *Evaluation `json:"evaluation,omitempty"`
+}
+
+// SelfTrain is the same as SelfTrainContext
+// but can't be cancelled
+func (m Models) SelfTrain(lang Language, unlabeled Corpus, opts *SelfTrainOptions) (*SelfTrainResult, error) {
+	return m.SelfTrainContext(context.Background(), lang, unlabeled, opts)
+}
+
+// SelfTrainContext adapts the model for the
+// language (usually a restored one) to a new
+// domain with unlabeled text from it, like a
+// directory read by NewUnlabeledDirCorpus.
+// Each round the model predicts the class of
+// every unlabeled document, and a copy of
+// the starting model learns the documents
+// predicted with at least opts.Confidence,
+// becoming the model for the next round.
+// Starting from the same model every round
+// means documents aren't learned twice, and
+// pseudo-labels the model grows out of are
+// forgotten.
+//
+// The corpus' labels are ignored, and it's
+// walked once per round so it has to walk
+// its documents in the same order every
+// time, which every corpus in this package
+// does. The model is only replaced with the
+// self-trained one once every round is done.
+// opts can be nil to self-train with the
+// defaults.
+func (m Models) SelfTrainContext(ctx context.Context, lang Language, unlabeled Corpus, opts *SelfTrainOptions) (*SelfTrainResult, error) {
+	start, ok := m[lang]
+	if !ok {
+		return nil, fmt.Errorf("ERROR: no model for language < %v > to self-train", lang)
+	}
+
+	rounds, confidence := 3, 0.9
+	if opts != nil && opts.Rounds > 0 {
+		rounds = opts.Rounds
+	}
+	if opts != nil && opts.Confidence > 0 {
+		confidence = opts.Confidence
+	}
+	if confidence > 1 {
+		return nil, fmt.Errorf("ERROR: confidence must be at most 1, not %v", confidence)
+	}
+
+	result := &SelfTrainResult{}
+	if opts != nil && opts.Test != nil {
+		e, err := start.Evaluate(opts.Test, 0)
+		if err != nil {
+			return nil, fmt.Errorf("Error evaluating the starting model!\n\t%w\n", err)
+		}
+		e.Language = lang
+		result.Baseline = e
+		opts.logf("self-training: starting accuracy %.4f", e.Accuracy)
+	}
+
+	classes := len(start.Count)
+	current := start
+	var predictions []uint8
+	for r := 1; r <= rounds; r++ {
+		next, err := start.clone()
+		if err != nil {
+			return nil, fmt.Errorf("Error copying the starting model!\n\t%w\n", err)
+		}
+
+		round := &SelfTrainRound{
+			Round:     r,
+			Classes:   make([]int, classes),
+			Predicted: make([]int, classes),
+		}
+
+		var index int
+		var batch []Document
+		err = unlabeled.Walk(func(doc Document, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return nil
+			}
+
+			current.mu.RLock()
+			class, probs := current.probabilities(doc.Text)
+			current.mu.RUnlock()
+
+			if index < len(predictions) {
+				if predictions[index] != class {
+					round.Changed++
+				}
+				predictions[index] = class
+			} else {
+				predictions = append(predictions, class)
+			}
+			index++
+			round.Predicted[class]++
+
+			if probs[class] < confidence {
+				return nil
+			}
+			round.Learned++
+			round.Classes[class]++
+
+			batch = append(batch, Document{Path: doc.Path, Text: doc.Text, Class: class})
+			if len(batch) < 1000 {
+				return nil
+			}
+			err = next.LearnBatch(batch)
+			batch = batch[:0]
+			return err
+		})
+		if err == nil && len(batch) > 0 {
+			err = next.LearnBatch(batch)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error self-training round %v!\n\t%w\n", r, err)
+		}
+		result.Documents = index
+
+		if opts != nil && opts.Test != nil {
+			e, err := next.Evaluate(opts.Test, 0)
+			if err != nil {
+				return nil, fmt.Errorf("Error evaluating self-training round %v!\n\t%w\n", r, err)
+			}
+			e.Language = lang
+			round.Evaluation = e
+			opts.logf("self-training: round %v learned %v of %v documents %v, %v changed, accuracy %.4f", r, round.Learned, index, round.Classes, round.Changed, e.Accuracy)
+		} else {
+			opts.logf("self-training: round %v learned %v of %v documents %v, %v changed", r, round.Learned, index, round.Classes, round.Changed)
+		}
+
+		result.Rounds = append(result.Rounds, round)
+		current = next
+	}
+
+	if current != start {
+		current.selfTrained(unlabeled, result)
+	}
+
+	m[lang] = current
+	return result, nil
+}
+
+// selfTrained records self-training in the
+// model's manifest, along with the last
+// round's evaluation if there was one
+func (m *Model) selfTrained(unlabeled Corpus, result *SelfTrainResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	manifest := &Manifest{}
+	if m.Manifest != nil {
+		*manifest = *m.Manifest
+	}
+
+	now := time.Now().UTC()
+	manifest.TrainedAt = &now
+	description := fmt.Sprintf("self-trained for %v rounds on %v", len(result.Rounds), describeCorpus(unlabeled))
+	if manifest.Corpus != "" {
+		description = manifest.Corpus + ", " + description
+	}
+	manifest.Corpus = description
+
+	if e := result.Rounds[len(result.Rounds)-1].Evaluation; e != nil {
+		evaluation := *e
+		evaluation.Misclassified = nil
+		manifest.Evaluation = &evaluation
+	}
+
+	m.Manifest = manifest
+}
+
+// logf logs to the options' logger, if
+// there is one
+func (o *SelfTrainOptions) logf(format string, v ...interface{}) {
+	if o == nil || o.Logger == nil {
+		return
+	}
+
+	o.Logger.Printf(format, v...)
+}
+
+// clone returns a deep copy of the model,
+// with the same sanitizer and tokenizers
+func (m *Model) clone() (*Model, error) {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Model{}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c.UpdateSanitize(m.sanitizer())
+	c.UpdateTokenizer(m.Tokenizer)
+	c.Output = ioutil.Discard
+	if c.Ratings != nil && m.Ratings != nil {
+		c.Ratings.UpdateTokenizer(m.Ratings.Tokenizer)
+		c.Ratings.Output = ioutil.Discard
+	}
+
+	return c, c.prepareVariant()
+}
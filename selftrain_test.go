@@ -0,0 +1,126 @@
+package sentiment
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+// productReviews is unlabeled text from
+// another domain, where the words about
+// products only show up next to words the
+// movie review model knows
+var productReviews = &DirCorpus{
+	FS: fstest.MapFS{
+		"1.txt": {Data: []byte("a wonderful and delightful sturdy blender")},
+		"2.txt": {Data: []byte("an awful and boring leaky toaster")},
+		"3.txt": {Data: []byte("a lovely and charming quiet kettle")},
+		"4.txt": {Data: []byte("a boring and awful loud kettle")},
+		"5.txt": {Data: []byte("")},
+	},
+	Classes: ClassDirs{0: "."},
+}
+
+var productTest = Documents{
+	{Text: "sturdy and quiet", Class: 1},
+	{Text: "leaky and loud", Class: 0},
+}
+
+func TestSelfTrainShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Priors: []float64{0.5, 0.5}})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+	start := models[English]
+
+	result, err := models.SelfTrain(English, productReviews, &SelfTrainOptions{
+		Rounds:     2,
+		Confidence: 0.7,
+		Test:       productTest,
+	})
+	if err != nil {
+		t.Fatalf("Self-training should not return an error!\n\t%v\n", err)
+	}
+
+	if result.Documents != 4 || len(result.Rounds) != 2 {
+		t.Fatalf("Self-training should score 4 documents for 2 rounds\n\treturned %v documents, %v rounds\n", result.Documents, len(result.Rounds))
+	}
+
+	first := result.Rounds[0]
+	if first.Learned != 4 || first.Classes[0] != 2 || first.Classes[1] != 2 || first.Changed != 0 {
+		t.Errorf("First round should learn 2 documents of each class\n\treturned %+v\n", first)
+	}
+	if result.Baseline.Accuracy >= 1 || result.Rounds[1].Evaluation.Accuracy != 1 {
+		t.Errorf("Self-training should improve held out accuracy\n\treturned %v then %v\n", result.Baseline.Accuracy, result.Rounds[1].Evaluation.Accuracy)
+	}
+
+	if models[English] == start {
+		t.Errorf("Self-training should replace the model\n")
+	}
+	if _, ok := start.Words.Get("leaky"); ok {
+		t.Errorf("Self-training should not change the starting model\n")
+	}
+	if class := models[English].Predict("a leaky toaster"); class != 0 {
+		t.Errorf("Self-trained model should predict < a leaky toaster > as 0\n\treturned %v\n", class)
+	}
+}
+
+func TestSelfTrainShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	vocabulary := []string{"awful", "boring", "charming", "delightful", "lovely", "wonderful"}
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Vocabulary: vocabulary})
+	if err != nil {
+		t.Fatalf("Training with a pruned vocabulary should not return an error!\n\t%v\n", err)
+	}
+	start := models[English]
+
+	_, err = models.SelfTrain(English, productReviews, &SelfTrainOptions{Rounds: 1, Confidence: 0.5})
+	if err != nil {
+		t.Fatalf("Self-training a pruned model should not return an error!\n\t%v\n", err)
+	}
+
+	// a pruned model learns the words in its
+	// vocabulary, and only those
+	for _, word := range vocabulary {
+		before, _ := start.Words.Get(word)
+		after, _ := models[English].Words.Get(word)
+		if after.Count[0]+after.Count[1] <= before.Count[0]+before.Count[1] {
+			t.Errorf("Self-training a pruned model should count < %v > again\n\tbefore %v\n\tafter %v\n", word, before.Count, after.Count)
+		}
+	}
+	if _, ok := models[English].Words.Get("kettle"); ok {
+		t.Errorf("Self-training a pruned model should not learn words outside of its vocabulary\n")
+	}
+}
+
+func TestSelfTrainShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, nil)
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+	start := models[English]
+
+	if _, err := models.SelfTrain(Language("xx"), productReviews, nil); err == nil {
+		t.Errorf("Self-training without a model should return an error\n")
+	}
+	if _, err := models.SelfTrain(English, productReviews, &SelfTrainOptions{Confidence: 2}); err == nil {
+		t.Errorf("Self-training with a confidence above 1 should return an error\n")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := models.SelfTrainContext(ctx, English, productReviews, nil); err != context.Canceled {
+		t.Errorf("Self-training with a cancelled context should return context.Canceled\n\treturned %v\n", err)
+	}
+	if models[English] != start {
+		t.Errorf("Failed self-training should not replace the model\n")
+	}
+}
@@ -12,6 +12,10 @@ func (m Models) SentimentAnalysis(sentence string, lang Language) *Analysis {
 		lang = English
 	}
 
+	model := m[lang]
+	model.mu.RLock()
+	defer model.mu.RUnlock()
+
 	analysis := &Analysis{
 		Language: lang,
 		Words:    []Score{},
@@ -23,22 +27,101 @@ func (m Models) SentimentAnalysis(sentence string, lang Language) *Analysis {
 		analysis.Sentences = []SentenceScore{}
 
 		for _, s := range sentences {
+			score, class := model.classify(s)
 			analysis.Sentences = append(analysis.Sentences, SentenceScore{
 				Sentence: s,
-				Score:    m[lang].Predict(s),
+				Score:    score,
+				Class:    class,
 			})
 		}
 	}
 
 	w := strings.Split(sentence, " ")
 	for _, word := range w {
+		score, class := model.classify(word)
 		analysis.Words = append(analysis.Words, Score{
 			Word:  word,
-			Score: m[lang].Predict(word),
+			Score: score,
+			Class: class,
 		})
 	}
 
-	analysis.Score = m[lang].Predict(sentence)
+	analysis.Score, analysis.Class = model.classify(sentence)
+
+	if model.Ratings != nil {
+		analysis.Stars, analysis.Rating = model.rating(sentence)
+	}
 
 	return analysis
 }
+
+// Predict predicts the class of the
+// sentence. It's safe to call while
+// the model is learning.
+func (m *Model) Predict(sentence string) uint8 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.predict(sentence)
+}
+
+// Probability returns the most likely class
+// of the sentence along with the probability
+// it's of that class. Unlike the underlying
+// text.NaiveBayes.Probability this won't
+// underflow on long documents, and it's safe
+// to call while the model is learning.
+func (m *Model) Probability(sentence string) (uint8, float64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	class, probs := m.probabilities(sentence)
+	return class, probs[class]
+}
+
+// Classify returns the sentiment of the
+// sentence along with the probability of
+// the class the model predicted. It's safe
+// to call while the model is learning.
+func (m *Model) Classify(sentence string) (Class, float64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	score, probs := m.probabilities(sentence)
+	return m.class(score, probs[score]), probs[score]
+}
+
+// SetNeutralThreshold sets the model's
+// NeutralThreshold. It's safe to call while
+// the model is in use.
+func (m *Model) SetNeutralThreshold(threshold float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.NeutralThreshold = threshold
+}
+
+// classify returns the class the model
+// predicts for the sentence along with its
+// sentiment. The caller must hold the
+// model's lock.
+func (m *Model) classify(sentence string) (uint8, Class) {
+	if m.NeutralThreshold <= 0 || len(m.Count) != 2 {
+		score := m.predict(sentence)
+		return score, Class(score)
+	}
+
+	score, probs := m.probabilities(sentence)
+	return score, m.class(score, probs[score])
+}
+
+// class returns the sentiment of a document
+// the model predicted is of class score with
+// probability p
+func (m *Model) class(score uint8, p float64) Class {
+	if len(m.Count) == 2 && m.NeutralThreshold > 0 && p < m.NeutralThreshold {
+		return Neutral
+	}
+
+	return Class(score)
+}
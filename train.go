@@ -0,0 +1,507 @@
+package sentiment
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cdipaolo/goml/base"
+	"github.com/cdipaolo/goml/text"
+)
+
+// TrainOptions configures how models are
+// trained. A nil (or zero) TrainOptions
+// trains silently with the defaults.
+type TrainOptions struct {
+	// Classes is the number of classes
+	// documents are labeled with: 2 for
+	// Negative and Positive (the default) or
+	// 3 to train a model which knows Neutral
+	// documents as well
+	Classes int
+
+	// Progress, if set, is called with the
+	// state of training every
+	// ProgressInterval documents and once
+	// more when training is done
+	Progress func(Progress)
+
+	// ProgressInterval is how many documents
+	// are learned between calls to Progress.
+	// It defaults to 500.
+	ProgressInterval int
+
+	// Logger, if set, is used to log when
+	// training starts and finishes, and any
+	// documents the model failed to learn
+	Logger *log.Logger
+
+	// Strict aborts training on the first
+	// document that can't be read or learned.
+	// Otherwise failed documents are skipped
+	// and recorded in the TrainResult.
+	Strict bool
+
+	// MaxErrors is the most documents that
+	// can fail before training is aborted.
+	// Zero means there's no limit.
+	MaxErrors int
+
+	// Sanitize is the sanitization function
+	// documents are cleaned with before being
+	// tokenized. It defaults to base.OnlyWords.
+	// Models don't persist it, so set it
+	// again with UpdateSanitize after
+	// restoring a model trained with
+	// anything else.
+	Sanitize func(rune) bool
+
+	// Workers is the number of goroutines
+	// reading and sanitizing documents while
+	// the model learns. Documents are still
+	// learned in the order the corpus walks
+	// them, so the trained model is the same
+	// for any number of workers. Zero (or
+	// one) reads documents one at a time.
+	Workers int
+
+	// Tokenizer splits sanitized documents
+	// into words. It defaults to splitting on
+	// spaces. Like Sanitize it isn't persisted,
+	// so set it again with UpdateTokenizer
+	// after restoring a model.
+	Tokenizer text.Tokenizer
+
+	// Vocabulary, if set, is the only words
+	// the model keeps once it's trained, like
+	// the IMDB dataset's datasets/imdb.vocab
+	// (see ReadVocabulary)
+	Vocabulary []string
+
+	// MinDocFrequency drops every word that's
+	// in fewer than this many documents
+	MinDocFrequency int
+
+	// MaxVocabulary is the most words the
+	// model keeps, favoring the ones in the
+	// most documents. Zero means there's no
+	// limit.
+	MaxVocabulary int
+
+	// Stopwords are words the model leaves
+	// out, like EnglishStopwords
+	Stopwords []string
+
+	// Balance is how to make up for classes
+	// with more documents than others (see
+	// Undersample, Oversample and Reweight.)
+	// By default every document is learned
+	// once.
+	Balance Balance
+
+	// Priors, if set, are the probabilities
+	// of each class before the model has read
+	// a document, in place of the share of
+	// the corpus each class makes up. They're
+	// normalized to sum to 1 and are kept
+	// while the model learns online.
+	// Complement models don't use priors, so
+	// they're recorded but ignored.
+	Priors []float64
+
+	// Variant is the Naive Bayes event model
+	// to train (see Multinomial, Bernoulli and
+	// Complement.) It defaults to Multinomial.
+	Variant Variant
+
+	// Smoothing is the additive smoothing
+	// constant α the model estimates word
+	// probabilities with. It defaults to 1
+	// (Laplace smoothing.)
+	Smoothing float64
+
+	// Description describes the corpus in
+	// the model's manifest, like "IMDB movie
+	// reviews". It defaults to a description
+	// of the corpus' type and files.
+	Description string
+}
+
+// TrainResult summarizes a training run
+type TrainResult struct {
+	// Documents is the number of documents
+	// the model learned, with Classes[i] of
+	// them being of class i. Oversampled
+	// documents are counted every time
+	// they're learned.
+	Documents int   `json:"documents"`
+	Classes   []int `json:"classes"`
+
+	// Failed holds the documents that
+	// couldn't be read or learned and were
+	// skipped (or, if training was aborted,
+	// the ones that caused it)
+	Failed []*DocumentError `json:"failed,omitempty"`
+
+	// Vocabulary is the number of words
+	// the model knows
+	Vocabulary int `json:"vocabulary"`
+
+	// Elapsed is how long training took
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Progress is a snapshot of a model
+// being trained
+type Progress struct {
+	// Documents is the number of documents
+	// passed to the model so far, with
+	// Classes[i] of them being of class i
+	Documents int
+	Classes   []int
+
+	// Elapsed is how long training has
+	// been running
+	Elapsed time.Duration
+
+	// Errors is the number of documents
+	// the model failed to learn
+	Errors int
+
+	// Done is set on the final call,
+	// once training is finished
+	Done bool
+}
+
+const defaultProgressInterval = 500
+
+// progressInterval returns the number of
+// documents between progress reports
+func (o *TrainOptions) progressInterval() int {
+	if o == nil || o.ProgressInterval <= 0 {
+		return defaultProgressInterval
+	}
+
+	return o.ProgressInterval
+}
+
+// overBudget returns whether the given
+// number of failed documents is enough
+// to abort training
+func (o *TrainOptions) overBudget(failed int) bool {
+	switch {
+	case o == nil:
+		return false
+	case o.Strict:
+		return failed > 0
+	default:
+		return o.MaxErrors > 0 && failed > o.MaxErrors
+	}
+}
+
+// classes returns the number of classes
+// to train with
+func (o *TrainOptions) classes() int {
+	if o == nil || o.Classes == 0 {
+		return 2
+	}
+
+	return o.Classes
+}
+
+// classesFor returns the number of classes
+// to train with off of a corpus that needs
+// the given number to hold every document:
+// opts.Classes if it's set, or else as many
+// as the corpus needs, but at least 2
+func (o *TrainOptions) classesFor(needed int) int {
+	if o != nil && o.Classes != 0 {
+		return o.Classes
+	}
+	if needed > 2 {
+		return needed
+	}
+
+	return 2
+}
+
+// sanitizer returns the sanitization
+// function to train with
+func (o *TrainOptions) sanitizer() func(rune) bool {
+	if o == nil || o.Sanitize == nil {
+		return base.OnlyWords
+	}
+
+	return o.Sanitize
+}
+
+// logf logs to the options' logger, if
+// there is one
+func (o *TrainOptions) logf(format string, v ...interface{}) {
+	if o == nil || o.Logger == nil {
+		return
+	}
+
+	o.Logger.Printf(format, v...)
+}
+
+// trainModel trains a sentiment model with
+// the given number of classes off of the
+// labeled documents in the corpus
+func trainModel(ctx context.Context, corpus Corpus, classes int, opts *TrainOptions) (*Model, *TrainResult, error) {
+	model, result, err := trainNaiveBayes(ctx, corpus, classes, func(doc Document) (uint8, bool) {
+		return doc.Class, true
+	}, opts)
+	if err != nil {
+		return nil, result, err
+	}
+
+	var vocabulary []string
+	if opts.prunes() {
+		model, vocabulary, err = pruneVocabulary(model, opts)
+		if err != nil {
+			return nil, result, err
+		}
+		opts.logf("sentiment: pruned vocabulary from %v to %v words", result.Vocabulary, len(vocabulary))
+		result.Vocabulary = len(vocabulary)
+	}
+
+	m := &Model{
+		NaiveBayes: model,
+		sanitize:   opts.sanitizer(),
+		Pruned:     opts.prunes(),
+		Vocabulary: vocabulary,
+	}
+	if opts != nil {
+		m.Balance = opts.Balance
+		m.Priors = opts.priors()
+		m.Variant = opts.Variant
+		m.Smoothing = opts.Smoothing
+	}
+
+	err = m.prepareVariant()
+	if err != nil {
+		return nil, result, err
+	}
+
+	return m, result, nil
+}
+
+// trainNaiveBayes trains a Naive Bayes model
+// with the given number of classes off of
+// the corpus. label returns the class to
+// learn each document as, or false if the
+// document should be skipped. The result is
+// returned even if training fails so the
+// failed documents can be reported.
+//
+// If ctx is cancelled the walk is stopped,
+// the learner is left to finish what it was
+// already sent, and ctx.Err() is returned.
+func trainNaiveBayes(ctx context.Context, corpus Corpus, classes int, label func(Document) (uint8, bool), opts *TrainOptions) (*text.NaiveBayes, *TrainResult, error) {
+	// text.NewNaiveBayes takes the number of
+	// classes as a uint8
+	if classes < 2 || classes > math.MaxUint8 {
+		return nil, nil, fmt.Errorf("ERROR: models need between 2 and %v classes, not %v", math.MaxUint8, classes)
+	}
+	err := opts.validateBalance(classes)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = opts.validateVariant()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &TrainResult{
+		Classes: make([]int, classes),
+	}
+
+	// resampling needs to know how big
+	// each class is before training starts
+	copies := func(class uint8) int { return 1 }
+	if opts.resamples() {
+		counts, err := countClasses(ctx, corpus, classes, label)
+		if err != nil {
+			return nil, result, err
+		}
+
+		opts.logf("sentiment: %v %v documents per class", opts.Balance, counts)
+		copies = resampler(opts.Balance, counts)
+	}
+
+	stream := make(chan base.TextDatapoint, 1000)
+	errors := make(chan error, 100)
+	// with workers, documents are sanitized
+	// before they get to the model, so it
+	// doesn't need to again
+	workers := 1
+	if opts != nil && opts.Workers > 1 {
+		workers = opts.Workers
+	}
+	prepare := func(doc Document) (Document, error) {
+		return doc, nil
+	}
+	modelSanitizer := opts.sanitizer()
+	if workers > 1 {
+		sanitizer := opts.sanitizer()
+		prepare = func(doc Document) (Document, error) {
+			doc.Text = sanitize(doc.Text, sanitizer)
+			return doc, nil
+		}
+		modelSanitizer = func(rune) bool { return false }
+	}
+
+	model := text.NewNaiveBayes(stream, uint8(classes), modelSanitizer)
+	model.Output = ioutil.Discard
+	if opts != nil && opts.Tokenizer != nil {
+		model.UpdateTokenizer(opts.Tokenizer)
+	}
+	tokenizer := model.Tokenizer
+	if opts.variant() == Bernoulli {
+		// Bernoulli models count documents,
+		// not words
+		model.UpdateTokenizer(uniqueTokenizer{tokenizer})
+	}
+
+	go model.OnlineLearn(errors)
+
+	// mu guards result, which is written
+	// to by both the walk and the goroutine
+	// draining the model's errors
+	var mu sync.Mutex
+
+	// fail records a failed document,
+	// returning an error if that puts
+	// training over its error budget
+	fail := func(e *DocumentError) error {
+		opts.logf("sentiment: skipping document: %v", e)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		result.Failed = append(result.Failed, e)
+		if !opts.overBudget(len(result.Failed)) {
+			return nil
+		}
+		if opts.Strict {
+			return e
+		}
+		return fmt.Errorf("%v documents failed, more than the %v allowed. Last failure: %w", len(result.Failed), opts.MaxErrors, e)
+	}
+
+	// drain the model's errors as they come
+	// in so the learner never blocks on them
+	drained := make(chan struct{})
+	go func() {
+		for e := range errors {
+			fail(&DocumentError{Err: e})
+		}
+		close(drained)
+	}()
+
+	now := time.Now()
+	report := func(done bool) {
+		mu.Lock()
+		result.Elapsed = time.Since(now)
+		p := Progress{
+			Documents: result.Documents,
+			Classes:   append([]int(nil), result.Classes...),
+			Elapsed:   result.Elapsed,
+			Errors:    len(result.Failed),
+			Done:      done,
+		}
+		mu.Unlock()
+
+		if opts != nil && opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+
+	opts.logf("sentiment: training %v class model from %T", classes, corpus)
+
+	interval := opts.progressInterval()
+	err = walkOrdered(corpus, workers, prepare, func(doc Document, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			e, ok := err.(*DocumentError)
+			if !ok {
+				e = &DocumentError{Path: doc.Path, Err: err}
+			}
+			return fail(e)
+		}
+
+		class, ok := label(doc)
+		if !ok {
+			return nil
+		}
+		if int(class) >= classes {
+			return fail(&DocumentError{
+				Path: doc.Path,
+				Err:  fmt.Errorf("class %v is out of range, the model only has %v classes", class, classes),
+			})
+		}
+
+		n := copies(class)
+		for i := 0; i < n; i++ {
+			select {
+			case stream <- base.TextDatapoint{X: doc.Text, Y: class}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		mu.Lock()
+		result.Documents += n
+		result.Classes[class] += n
+		ct := result.Documents
+		mu.Unlock()
+
+		if n > 0 && ct/interval != (ct-n)/interval {
+			report(false)
+		}
+
+		return nil
+	})
+
+	close(stream)
+	<-drained
+
+	if err == nil && opts.overBudget(len(result.Failed)) {
+		// the learner failed documents after
+		// the walk was done with them
+		err = fmt.Errorf("%v documents failed, more than the %v allowed", len(result.Failed), opts.MaxErrors)
+	}
+	if err != nil {
+		result.Elapsed = time.Since(now)
+		return nil, result, err
+	}
+
+	model.UpdateTokenizer(tokenizer)
+	if opts != nil && opts.Balance == Reweight {
+		err = reweight(model)
+		if err != nil {
+			return nil, result, err
+		}
+	}
+	if priors := opts.priors(); priors != nil {
+		copy(model.Probabilities, priors)
+	}
+
+	model.UpdateSanitize(opts.sanitizer())
+	result.Vocabulary = int(model.DictCount)
+
+	report(true)
+
+	if result.Documents > 0 {
+		opts.logf("sentiment: trained on %v documents %v in %v (%v per document), skipped %v", result.Documents, result.Classes, result.Elapsed, result.Elapsed/time.Duration(result.Documents), len(result.Failed))
+	}
+
+	return model, result, nil
+}
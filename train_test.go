@@ -0,0 +1,210 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// messyFS holds a line corpus with 2
+// malformed lines among 4 good ones
+var messyFS = fstest.MapFS{
+	"opinions.txt": {Data: []byte("1\tI love it\n0\tI hate it\nno tab\n1\tgreat stuff\n7\tthis class is out of range\n0\tawful stuff\n")},
+}
+
+func TestTrainSkipsFailedDocumentsShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	result, err := TrainEnglishModelCorpus(models, &LineCorpus{FS: messyFS, Name: "opinions.txt"}, nil)
+	if err != nil {
+		t.Fatalf("Training without an error budget should skip failed documents!\n\t%v\n", err)
+	}
+
+	if result.Documents != 4 || result.Classes[0] != 2 || result.Classes[1] != 2 {
+		t.Errorf("Training should learn the 4 good documents\n\treturned %+v\n", result)
+	}
+
+	if len(result.Failed) != 2 || result.Failed[0].Path != "opinions.txt:3" || result.Failed[1].Path != "opinions.txt:5" {
+		t.Errorf("Training should record the 2 failed documents\n\treturned %+v\n", result.Failed)
+	}
+
+	if models[English].DocumentCount != 4 {
+		t.Errorf("Model should have learned 4 documents\n\treturned %v\n", models[English].DocumentCount)
+	}
+}
+
+func TestTrainErrorBudgetShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	corpus := &LineCorpus{FS: messyFS, Name: "opinions.txt"}
+
+	for _, opts := range []*TrainOptions{
+		{Strict: true},
+		{MaxErrors: 1},
+	} {
+		models := make(Models)
+		result, err := TrainEnglishModelCorpus(models, corpus, opts)
+		if err == nil {
+			t.Errorf("Training over the error budget %+v should return an error!\n", opts)
+			continue
+		}
+
+		var docErr *DocumentError
+		if opts.Strict && (!errors.As(err, &docErr) || docErr.Path != "opinions.txt:3") {
+			t.Errorf("Strict training should fail with the first failed document\n\treturned %v\n", err)
+		}
+
+		if result == nil || len(result.Failed) == 0 {
+			t.Errorf("Failed training should still report the failed documents\n\treturned %+v\n", result)
+		}
+
+		if _, ok := models[English]; ok {
+			t.Errorf("Failed training should not add a model to the map!\n")
+		}
+	}
+
+	_, err := TrainEnglishModelCorpus(make(Models), corpus, &TrainOptions{MaxErrors: 2})
+	if err != nil {
+		t.Errorf("Training within the error budget should not return an error!\n\t%v\n", err)
+	}
+}
+
+func TestTrainWorkersShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	// a directory corpus big enough to keep
+	// the workers busy, with some unreadable
+	// and empty files mixed in
+	fsys := fstest.MapFS{}
+	for i := 0; i < 500; i++ {
+		fsys[fmt.Sprintf("pos/%v_9.txt", i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("wonderful %v delightful movie number%v", i, i%17))}
+		fsys[fmt.Sprintf("neg/%v_2.txt", i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("terrible %v boring movie number%v", i, i%13))}
+	}
+	fsys["neg/empty.txt"] = &fstest.MapFile{}
+	fsys["pos/unreadable"] = &fstest.MapFile{Mode: fs.ModeSymlink, Data: []byte("nowhere")}
+
+	corpus := MultiCorpus(
+		&DirCorpus{FS: fsys, Classes: IMDBClassDirs},
+		&LineCorpus{FS: messyFS, Name: "opinions.txt"},
+	)
+
+	var expected []byte
+	var failed []*DocumentError
+	for _, workers := range []int{0, 1, 2, 8} {
+		models := make(Models)
+		result, err := TrainEnglishModelCorpus(models, corpus, &TrainOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("Training with %v workers should not return an error!\n\t%v\n", workers, err)
+		}
+
+		// the only thing that should differ
+		models[English].Manifest.TrainedAt = nil
+
+		bytes, err := json.Marshal(models)
+		if err != nil {
+			t.Fatalf("Marshaling a model should not return an error!\n\t%v\n", err)
+		}
+
+		if expected == nil {
+			expected, failed = bytes, result.Failed
+			continue
+		}
+
+		if string(bytes) != string(expected) {
+			t.Errorf("Model trained with %v workers should be the same as with none\n", workers)
+		}
+
+		if fmt.Sprint(result.Failed) != fmt.Sprint(failed) {
+			t.Errorf("Documents failed with %v workers should be the same as with none\n\treturned %v\n\texpected %v\n", workers, result.Failed, failed)
+		}
+	}
+
+	if len(failed) != 3 {
+		t.Errorf("Training should skip 3 documents\n\treturned %v\n", failed)
+	}
+}
+
+func TestTrainWorkersShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	_, err := TrainEnglishModelCorpus(make(Models), &LineCorpus{FS: messyFS, Name: "opinions.txt"}, &TrainOptions{
+		Workers: 4,
+		Strict:  true,
+	})
+
+	var docErr *DocumentError
+	if !errors.As(err, &docErr) || docErr.Path != "opinions.txt:3" {
+		t.Errorf("Strict training with workers should fail with the first failed document\n\treturned %v\n", err)
+	}
+}
+
+func TestTrainContextShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelCorpusContext(ctx, models, &DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs}, nil)
+	if err != context.Canceled {
+		t.Errorf("Training with a cancelled context should return context.Canceled\n\treturned %v\n", err)
+	}
+
+	if _, ok := models[English]; ok {
+		t.Errorf("Cancelled training should not add a model to the map!\n")
+	}
+}
+
+func TestTrainContextShouldFail2(t *testing.T) {
+	t.Parallel()
+
+	for _, workers := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		models := make(Models)
+		result, err := TrainEnglishModelCorpusContext(ctx, models, &DirCorpus{FS: fixtureFS, Classes: IMDBClassDirs}, &TrainOptions{
+			Workers:          workers,
+			ProgressInterval: 2,
+			Progress: func(p Progress) {
+				cancel()
+			},
+		})
+		if err != context.Canceled {
+			t.Errorf("Training cancelled partway through (%v workers) should return context.Canceled\n\treturned %v\n", workers, err)
+		}
+
+		if result == nil || result.Documents != 2 {
+			t.Errorf("Training cancelled after 2 documents (%v workers) should stop walking\n\treturned %+v\n", workers, result)
+		}
+	}
+}
+
+func TestTrainClassesShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	// class 255 would need a 256th class,
+	// which doesn't fit in a uint8
+	fsys := fstest.MapFS{
+		"a/0.txt": {Data: []byte("a wonderful movie")},
+		"b/0.txt": {Data: []byte("a terrible movie")},
+	}
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fsys, ClassDirs{0: "a", 255: "b"}, nil)
+	if err == nil {
+		t.Errorf("Training with 256 classes should return an error\n")
+	}
+	if _, ok := models[English]; ok {
+		t.Errorf("Failed training should not add a model to the map!\n")
+	}
+
+	_, err = TrainModel(make(Models), English, Documents{{Text: "fine"}}, &TrainOptions{Classes: 256})
+	if err == nil {
+		t.Errorf("Training with 256 classes should return an error\n")
+	}
+}
@@ -0,0 +1,393 @@
+package sentiment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/cdipaolo/goml/text"
+)
+
+// Variant is the event model a Naive
+// Bayes classifier is trained and
+// scored with
+type Variant string
+
+// Constants hold the Naive Bayes variants.
+// The zero value is Multinomial.
+const (
+	// Multinomial counts every time a word
+	// is used, scoring words the same way
+	// text.NaiveBayes does:
+	//	P(w|c) = (count(w, c) + α) / (count(w) + αV)
+	// With the default smoothing (α = 1) it's
+	// exactly text.NaiveBayes.
+	Multinomial Variant = "multinomial"
+
+	// Bernoulli only counts whether a word is
+	// in a document or not, and also scores
+	// documents by the words they don't use.
+	// It tends to do well on short texts.
+	Bernoulli Variant = "bernoulli"
+
+	// Complement scores each class by how
+	// unlike every other class a document
+	// is, which holds up much better than
+	// Multinomial when the classes aren't
+	// balanced. It doesn't use class priors.
+	Complement Variant = "complement"
+)
+
+// validateVariant checks the options'
+// Naive Bayes variant and smoothing
+func (o *TrainOptions) validateVariant() error {
+	if o == nil {
+		return nil
+	}
+
+	switch o.Variant {
+	case "", Multinomial, Bernoulli, Complement:
+	default:
+		return fmt.Errorf("unknown Naive Bayes variant < %v >", o.Variant)
+	}
+
+	if o.Smoothing < 0 || math.IsNaN(o.Smoothing) || math.IsInf(o.Smoothing, 0) {
+		return fmt.Errorf("smoothing must be positive, not %v", o.Smoothing)
+	}
+
+	return nil
+}
+
+// variant returns the variant to train with
+func (o *TrainOptions) variant() Variant {
+	if o == nil || o.Variant == "" {
+		return Multinomial
+	}
+
+	return o.Variant
+}
+
+// variant returns the model's variant
+func (m *Model) variant() Variant {
+	if m.Variant == "" {
+		return Multinomial
+	}
+
+	return m.Variant
+}
+
+// alpha returns the model's smoothing
+// constant
+func (m *Model) alpha() float64 {
+	if m.Smoothing <= 0 {
+		return 1
+	}
+
+	return m.Smoothing
+}
+
+// predict returns the most likely class
+// of the sentence. The caller must hold
+// the model's lock.
+func (m *Model) predict(sentence string) uint8 {
+	if m.variant() == Multinomial && m.alpha() == 1 {
+		return m.NaiveBayes.Predict(sentence)
+	}
+
+	class, _ := normalize(m.logScores(sentence))
+	return class
+}
+
+// logScores returns the score of each class
+// for the sentence under the model's variant,
+// where the highest score is the most likely
+// class. The caller must hold the model's
+// lock.
+func (m *Model) logScores(sentence string) []float64 {
+	switch m.variant() {
+	case Bernoulli:
+		return m.bernoulliScores(sentence)
+	case Complement:
+		return m.complementScores(sentence)
+	default:
+		return logScores(m.NaiveBayes, m.sanitizer(), m.alpha(), sentence)
+	}
+}
+
+// bernoulliScores returns
+//
+//	log(P(y = c)) + Σ log(P(x|y = c)) + Σ log(1 - P(x'|y = c))
+//
+// for every class c, where x are the words in
+// the sentence and x' the ones that aren't.
+// The second sum is precomputed over every
+// word the model knows by prepareVariant.
+func (m *Model) bernoulliScores(sentence string) []float64 {
+	b := m.NaiveBayes
+	alpha := m.alpha()
+
+	absent := m.absent
+	if absent == nil {
+		// the model wasn't prepared, so
+		// work it out now
+		frequencies, _ := documentFrequencies(b)
+		absent = bernoulliAbsent(b, frequencies, alpha)
+	}
+
+	sums := make([]float64, len(b.Count))
+	copy(sums, absent)
+
+	seen := make(map[string]bool)
+	for _, word := range b.Tokenizer.Tokenize(sanitize(sentence, m.sanitizer())) {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		w, ok := b.Words.Get(word)
+		if !ok {
+			continue
+		}
+
+		for i := range sums {
+			p := bernoulli(w.Count[i], b.Count[i], alpha)
+			sums[i] += math.Log(p) - math.Log(1-p)
+		}
+	}
+
+	for i := range sums {
+		sums[i] += math.Log(b.Probabilities[i])
+	}
+
+	return sums
+}
+
+// complementScores returns
+//
+//	-Σ log(P(x|y ≠ c))
+//
+// for every class c, so the class the
+// sentence is least like the rest of is
+// the most likely
+func (m *Model) complementScores(sentence string) []float64 {
+	b := m.NaiveBayes
+	alpha := m.alpha()
+
+	tokens := m.ClassTokens
+	if len(tokens) != len(b.Count) {
+		// the model wasn't prepared, so
+		// work it out now
+		tokens, _ = classTokens(b)
+	}
+
+	var total float64
+	for _, n := range tokens {
+		total += float64(n)
+	}
+
+	sums := make([]float64, len(b.Count))
+	for _, word := range b.Tokenizer.Tokenize(sanitize(sentence, m.sanitizer())) {
+		w, ok := b.Words.Get(word)
+		if !ok {
+			continue
+		}
+
+		for i := range sums {
+			count := float64(w.Seen-w.Count[i]) + alpha
+			all := total - float64(tokens[i]) + alpha*float64(b.DictCount)
+			sums[i] -= math.Log(count / all)
+		}
+	}
+
+	return sums
+}
+
+// prepareVariant precomputes what the model's
+// variant needs to score documents. It has to
+// be called whenever a Bernoulli model learns
+// and when a model is restored. The caller
+// must hold the model's write lock.
+func (m *Model) prepareVariant() error {
+	var err error
+	switch m.variant() {
+	case Bernoulli:
+		if len(m.frequencies) != len(m.Count) {
+			m.frequencies, err = documentFrequencies(m.NaiveBayes)
+			if err != nil {
+				return err
+			}
+		}
+		m.absent = bernoulliAbsent(m.NaiveBayes, m.frequencies, m.alpha())
+	case Complement:
+		if len(m.ClassTokens) != len(m.Count) {
+			m.ClassTokens, err = classTokens(m.NaiveBayes)
+		}
+	}
+
+	return err
+}
+
+// bernoulli returns the smoothed probability
+// that a document of a class with n documents
+// uses a word that's in count of them
+func bernoulli(count, n uint64, alpha float64) float64 {
+	return (float64(count) + alpha) / (float64(n) + 2*alpha)
+}
+
+// bernoulliAbsent returns, for each class,
+// the log probability of a document of that
+// class not using any word the model knows.
+// Words in the same number of documents of
+// a class are equally likely to be absent,
+// so it only takes the model's document
+// frequencies.
+func bernoulliAbsent(b *text.NaiveBayes, frequencies []map[uint64]uint64, alpha float64) []float64 {
+	absent := make([]float64, len(b.Count))
+	for i := range absent {
+		// summed in order so the result
+		// doesn't depend on map order
+		counts := make([]uint64, 0, len(frequencies[i]))
+		for count := range frequencies[i] {
+			counts = append(counts, count)
+		}
+		sort.Slice(counts, func(a, b int) bool { return counts[a] < counts[b] })
+
+		for _, count := range counts {
+			words := float64(frequencies[i][count])
+			absent[i] += words * math.Log(1-bernoulli(count, b.Count[i], alpha))
+		}
+	}
+
+	return absent
+}
+
+// documentFrequencies returns, for each
+// class, how many of the words the model
+// knows are in each number of documents of
+// that class
+func documentFrequencies(b *text.NaiveBayes) ([]map[uint64]uint64, error) {
+	words, err := vocabulary(b)
+	if err != nil {
+		return nil, err
+	}
+
+	frequencies := make([]map[uint64]uint64, len(b.Count))
+	for i := range frequencies {
+		frequencies[i] = make(map[uint64]uint64)
+	}
+	for _, word := range words {
+		w, _ := b.Words.Get(word)
+		for i := range frequencies {
+			frequencies[i][w.Count[i]]++
+		}
+	}
+
+	return frequencies, nil
+}
+
+// batchWords returns the words the model
+// knows out of every word the documents
+// could teach it, along with how many
+// documents of each class they're in so far
+func (m *Model) batchWords(docs []Document) map[string][]uint64 {
+	words := make(map[string][]uint64)
+	for _, doc := range docs {
+		for _, word := range m.Tokenizer.Tokenize(sanitize(doc.Text, m.sanitizer())) {
+			if _, ok := words[word]; ok {
+				continue
+			}
+
+			// words the model doesn't know
+			// yet are nil
+			words[word] = nil
+			if w, ok := m.Words.Get(word); ok {
+				words[word] = append([]uint64(nil), w.Count...)
+			}
+		}
+	}
+
+	return words
+}
+
+// updateFrequencies moves the words a
+// Bernoulli model just learned (with the
+// counts batchWords returned before it
+// learned them) to their new document
+// frequencies. The caller must hold the
+// model's write lock.
+func (m *Model) updateFrequencies(before map[string][]uint64) {
+	for word, counts := range before {
+		w, ok := m.Words.Get(word)
+		if !ok {
+			continue
+		}
+
+		for i, frequency := range m.frequencies {
+			if counts != nil {
+				frequency[counts[i]]--
+				if frequency[counts[i]] == 0 {
+					delete(frequency, counts[i])
+				}
+			}
+			frequency[w.Count[i]]++
+		}
+	}
+}
+
+// classTokens returns the number of words
+// the model learned of each class
+func classTokens(b *text.NaiveBayes) ([]uint64, error) {
+	words, err := vocabulary(b)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]uint64, len(b.Count))
+	for _, word := range words {
+		w, _ := b.Words.Get(word)
+		for i := range tokens {
+			tokens[i] += w.Count[i]
+		}
+	}
+
+	return tokens, nil
+}
+
+// countTokens returns the number of words
+// in the sentence the model would learn
+// (the learner skips words shorter than
+// three bytes)
+func (m *Model) countTokens(sentence string) uint64 {
+	var n uint64
+	for _, word := range m.Tokenizer.Tokenize(sanitize(sentence, m.sanitizer())) {
+		if len(word) >= 3 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// uniqueTokenizer drops repeated words, so
+// a model learning through it counts the
+// documents each word is in rather than
+// how often it's used
+type uniqueTokenizer struct {
+	text.Tokenizer
+}
+
+func (t uniqueTokenizer) Tokenize(sentence string) []string {
+	words := t.Tokenizer.Tokenize(sentence)
+	seen := make(map[string]bool, len(words))
+
+	unique := words[:0]
+	for _, word := range words {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		unique = append(unique, word)
+	}
+
+	return unique
+}
@@ -0,0 +1,139 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVariantShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []*TrainOptions{
+		{Variant: Multinomial, Smoothing: 0.5},
+		{Variant: Bernoulli},
+		{Variant: Complement},
+	} {
+		models := make(Models)
+		_, err := TrainModel(models, English, skewed, opts)
+		if err != nil {
+			t.Fatalf("Training a %v model should not return an error!\n\t%v\n", opts.Variant, err)
+		}
+
+		m := models[English]
+		if m.Variant != opts.Variant || m.Smoothing != opts.Smoothing {
+			t.Errorf("Model should record its variant and smoothing\n\treturned %v, %v\n", m.Variant, m.Smoothing)
+		}
+
+		for sentence, class := range map[string]uint8{
+			"a wonderful and delightful story": 1,
+			"an awful and boring story":        0,
+		} {
+			if predicted := m.Predict(sentence); predicted != class {
+				t.Errorf("%v model should predict < %v > as %v\n\treturned %v\n", opts.Variant, sentence, class, predicted)
+			}
+		}
+
+		bytes, err := json.Marshal(models)
+		if err != nil {
+			t.Fatalf("Marshalling a %v model should not return an error!\n\t%v\n", opts.Variant, err)
+		}
+		restored, err := RestoreModels(bytes)
+		if err != nil {
+			t.Fatalf("Restoring a %v model should not return an error!\n\t%v\n", opts.Variant, err)
+		}
+
+		_, before := m.Probability("a wonderful story")
+		_, after := restored[English].Probability("a wonderful story")
+		if restored[English].Variant != opts.Variant || math.Abs(before-after) > 1e-9 {
+			t.Errorf("Restored %v model should score the same as the original\n\texpected %v\n\treturned %v (%v)\n", opts.Variant, before, after, restored[English].Variant)
+		}
+	}
+}
+
+func TestVariantShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	// the default smoothing is the same
+	// as text.NaiveBayes
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Variant: Multinomial, Smoothing: 1})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	m := models[English]
+	for _, sentence := range []string{"a wonderful film", "an awful story", "nothing known"} {
+		if class, _ := normalize(logScores(m.NaiveBayes, m.sanitizer(), m.alpha(), sentence)); class != m.NaiveBayes.Predict(sentence) {
+			t.Errorf("Multinomial scores of < %v > should match text.NaiveBayes\n", sentence)
+		}
+	}
+}
+
+func TestVariantLearnShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	for _, variant := range []Variant{Bernoulli, Complement} {
+		models := make(Models)
+		_, err := TrainModel(models, English, skewed, &TrainOptions{Variant: variant})
+		if err != nil {
+			t.Fatalf("Training a %v model should not return an error!\n\t%v\n", variant, err)
+		}
+
+		_, before := models[English].probabilities("dreadful")
+
+		err = models.Learn("a dreadful dreadful ending, entirely unseen", 0, English)
+		if err != nil {
+			t.Fatalf("Learning with a %v model should not return an error!\n\t%v\n", variant, err)
+		}
+
+		m := models[English]
+		w, _ := m.Words.Get("dreadful")
+		if variant == Bernoulli && w.Count[0] != 1 {
+			t.Errorf("Bernoulli model should count documents, not words\n\treturned %v\n", w.Count)
+		}
+
+		if variant == Bernoulli {
+			// kept up to date without going
+			// through every word
+			frequencies, _ := documentFrequencies(m.NaiveBayes)
+			if !reflect.DeepEqual(frequencies, m.frequencies) {
+				t.Errorf("Bernoulli model should keep its document frequencies up to date\n\texpected %v\n\treturned %v\n", frequencies, m.frequencies)
+			}
+
+			absent := bernoulliAbsent(m.NaiveBayes, frequencies, m.alpha())
+			for i := range absent {
+				if math.Abs(absent[i]-m.absent[i]) > 1e-9 {
+					t.Errorf("Bernoulli model should keep its absent word probabilities up to date\n\texpected %v\n\treturned %v\n", absent, m.absent)
+					break
+				}
+			}
+		}
+
+		if variant == Complement {
+			tokens, _ := classTokens(m.NaiveBayes)
+			if !reflect.DeepEqual(tokens, m.ClassTokens) {
+				t.Errorf("Complement model should keep its word counts up to date\n\texpected %v\n\treturned %v\n", tokens, m.ClassTokens)
+			}
+		}
+
+		if _, after := m.probabilities("dreadful"); after[0] <= before[0] {
+			t.Errorf("%v model should learn online\n\treturned %v before and %v after\n", variant, before, after)
+		}
+	}
+}
+
+func TestVariantShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []*TrainOptions{
+		{Variant: "gaussian"},
+		{Smoothing: -1},
+	} {
+		_, err := TrainModel(make(Models), English, skewed, opts)
+		if err == nil {
+			t.Errorf("Training with %+v should return an error\n", opts)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package sentiment
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cdipaolo/goml/text"
+)
+
+// EnglishStopwords are common English words
+// which say little about the sentiment of a
+// document, for use as TrainOptions.Stopwords.
+// Words shorter than three letters aren't
+// listed because models never learn them.
+var EnglishStopwords = []string{
+	"about", "above", "after", "again", "all", "also", "and", "any",
+	"are", "because", "been", "before", "being", "below", "between",
+	"both", "but", "can", "did", "does", "doing", "down", "during",
+	"each", "for", "from", "further", "had", "has", "have", "having",
+	"her", "here", "hers", "herself", "him", "himself", "his", "how",
+	"into", "its", "itself", "just", "more", "most", "myself", "now",
+	"off", "once", "only", "other", "our", "ours", "ourselves", "out",
+	"over", "own", "same", "she", "should", "some", "such", "than",
+	"that", "the", "their", "theirs", "them", "themselves", "then",
+	"there", "these", "they", "this", "those", "through", "too",
+	"under", "until", "very", "was", "were", "what", "when", "where",
+	"which", "while", "who", "whom", "why", "will", "with", "would",
+	"you", "your", "yours", "yourself", "yourselves",
+}
+
+// ReadVocabulary reads a vocabulary file with
+// one word per line, like the IMDB dataset's
+// datasets/imdb.vocab, for use as
+// TrainOptions.Vocabulary. Words are lower
+// cased to match the tokenizer and blank
+// lines are skipped.
+func ReadVocabulary(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" {
+			continue
+		}
+
+		words = append(words, word)
+	}
+
+	return words, scanner.Err()
+}
+
+// prunes returns whether the options
+// drop any words from the vocabulary
+func (o *TrainOptions) prunes() bool {
+	return o != nil && (o.Vocabulary != nil ||
+		o.MinDocFrequency > 1 ||
+		o.MaxVocabulary > 0 ||
+		len(o.Stopwords) > 0)
+}
+
+// pruneVocabulary drops the words the options
+// exclude from the freshly trained model,
+// returning the pruned model and the sorted
+// list of words it kept. Models that were
+// restored or learned online can't be pruned
+// since only training counts the number of
+// documents each word is in.
+func pruneVocabulary(b *text.NaiveBayes, opts *TrainOptions) (*text.NaiveBayes, []string, error) {
+	all, err := vocabulary(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var allowed map[string]bool
+	if opts.Vocabulary != nil {
+		allowed = make(map[string]bool, len(opts.Vocabulary))
+		for _, word := range opts.Vocabulary {
+			allowed[strings.ToLower(word)] = true
+		}
+	}
+	stopwords := make(map[string]bool, len(opts.Stopwords))
+	for _, word := range opts.Stopwords {
+		stopwords[strings.ToLower(word)] = true
+	}
+
+	words := make(map[string]text.Word, len(all))
+	kept := make([]string, 0, len(all))
+	for _, word := range all {
+		if (allowed != nil && !allowed[word]) || stopwords[word] {
+			continue
+		}
+
+		w, _ := b.Words.Get(word)
+		if w.DocsSeen < uint64(opts.MinDocFrequency) {
+			continue
+		}
+
+		words[word] = w
+		kept = append(kept, word)
+	}
+
+	if opts.MaxVocabulary > 0 && len(kept) > opts.MaxVocabulary {
+		// keep the words in the most documents,
+		// breaking ties alphabetically so the
+		// vocabulary is reproducible
+		sort.Slice(kept, func(i, j int) bool {
+			a, b := words[kept[i]].DocsSeen, words[kept[j]].DocsSeen
+			if a != b {
+				return a > b
+			}
+			return kept[i] < kept[j]
+		})
+		kept = kept[:opts.MaxVocabulary]
+		sort.Strings(kept)
+	}
+
+	pruned := text.NewNaiveBayes(nil, uint8(len(b.Count)), opts.sanitizer())
+	pruned.Output = ioutil.Discard
+	pruned.Tokenizer = b.Tokenizer
+	pruned.Count = b.Count
+	pruned.Probabilities = b.Probabilities
+	pruned.DocumentCount = b.DocumentCount
+	pruned.DictCount = uint64(len(kept))
+	for _, word := range kept {
+		pruned.Words.Set(word, words[word])
+	}
+
+	return pruned, kept, nil
+}
+
+// vocabulary returns every word the Naive
+// Bayes model knows, in sorted order. The
+// word map can only be read out through
+// its JSON encoding.
+func vocabulary(b *text.NaiveBayes) ([]string, error) {
+	bytes, err := b.Words.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var words map[string]json.RawMessage
+	err = json.Unmarshal(bytes, &words)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(words))
+	for word := range words {
+		list = append(list, word)
+	}
+	sort.Strings(list)
+
+	return list, nil
+}
+
+// restoreVocabulary works a decoded pruned
+// model's Vocabulary out again from the
+// words it knows, since it isn't persisted
+func (m *Model) restoreVocabulary() error {
+	m.Vocabulary = nil
+	if !m.Pruned {
+		return nil
+	}
+
+	words, err := vocabulary(m.NaiveBayes)
+	if err != nil {
+		return err
+	}
+	m.Vocabulary = words
+
+	return nil
+}
+
+// inVocabulary drops the words outside of
+// the model's vocabulary from the sentence,
+// so learning online doesn't grow a pruned
+// model back out. The words kept are joined
+// back together with spaces. The caller must
+// hold the model's write lock.
+func (m *Model) inVocabulary(sentence string) string {
+	if m.vocabulary == nil {
+		m.vocabulary = make(map[string]bool, len(m.Vocabulary))
+		for _, word := range m.Vocabulary {
+			m.vocabulary[word] = true
+		}
+	}
+
+	words := m.Tokenizer.Tokenize(sanitize(sentence, m.sanitizer()))
+	kept := words[:0]
+	for _, word := range words {
+		if m.vocabulary[word] {
+			kept = append(kept, word)
+		}
+	}
+
+	return strings.Join(kept, " ")
+}
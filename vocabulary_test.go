@@ -0,0 +1,149 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPruneVocabularyShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	result, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, &TrainOptions{
+		MinDocFrequency: 2,
+		Stopwords:       EnglishStopwords,
+	})
+	if err != nil {
+		t.Fatalf("Training with a pruned vocabulary should not return an error!\n\t%v\n", err)
+	}
+
+	expected := []string{"acting", "awful", "boring", "delightful", "ending", "happy", "movie", "music", "story", "terrible", "truly", "wonderful"}
+	m := models[English]
+	if !reflect.DeepEqual(m.Vocabulary, expected) {
+		t.Errorf("Vocabulary should be the non stopwords in at least 2 documents\n\texpected %v\n\treturned %v\n", expected, m.Vocabulary)
+	}
+	if int(m.DictCount) != len(expected) || result.Vocabulary != len(expected) {
+		t.Errorf("Model should know %v words\n\treturned %v (result says %v)\n", len(expected), m.DictCount, result.Vocabulary)
+	}
+	if _, ok := m.Words.Get("loved"); ok {
+		t.Errorf("Words in only one document should be pruned\n")
+	}
+
+	s := models.SentimentAnalysis("a wonderful and delightful film", English)
+	if s.Score != 1 {
+		t.Errorf("Pruned model should still find positive sentences positive\n\treturned %v\n", s.Score)
+	}
+
+	err = models.Learn("an unbelievably wonderful film", 1, English)
+	if err != nil {
+		t.Fatalf("Learning with a pruned model should not return an error!\n\t%v\n", err)
+	}
+	if _, ok := m.Words.Get("unbelievably"); ok {
+		t.Errorf("Learning should not add words outside of the vocabulary\n")
+	}
+	if int(m.DictCount) != len(expected) {
+		t.Errorf("Learning should not grow the vocabulary\n\treturned %v words\n", m.DictCount)
+	}
+
+	bytes, err := json.Marshal(models)
+	if err != nil {
+		t.Fatalf("Marshalling a pruned model should not return an error!\n\t%v\n", err)
+	}
+	if strings.Contains(string(bytes), `"vocabulary":[`) {
+		t.Errorf("A pruned model's vocabulary should not be persisted next to its words\n")
+	}
+	binary, err := models.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Encoding a pruned model should not return an error!\n\t%v\n", err)
+	}
+
+	for _, data := range [][]byte{bytes, binary} {
+		restored, err := RestoreModels(data)
+		if err != nil {
+			t.Fatalf("Restoring a pruned model should not return an error!\n\t%v\n", err)
+		}
+		if !restored[English].Pruned || !reflect.DeepEqual(restored[English].Vocabulary, expected) {
+			t.Errorf("Restored model should keep its vocabulary\n\treturned %v\n", restored[English].Vocabulary)
+		}
+
+		err = restored.Learn("unbelievably wonderful", 1, English)
+		if err != nil {
+			t.Fatalf("Learning with a restored pruned model should not return an error!\n\t%v\n", err)
+		}
+		if _, ok := restored[English].Words.Get("unbelievably"); ok {
+			t.Errorf("Learning should not add words outside of a restored model's vocabulary\n")
+		}
+	}
+
+	c, err := m.clone()
+	if err != nil {
+		t.Fatalf("Cloning a pruned model should not return an error!\n\t%v\n", err)
+	}
+	if !c.Pruned || !reflect.DeepEqual(c.Vocabulary, expected) {
+		t.Errorf("Cloned model should keep its vocabulary\n\treturned %v\n", c.Vocabulary)
+	}
+
+	before, _ := c.Words.Get("wonderful")
+	seen := before.Count[1]
+	err = c.LearnBatch([]Document{{Text: "wonderful", Class: 1}})
+	if err != nil {
+		t.Fatalf("Learning with a cloned pruned model should not return an error!\n\t%v\n", err)
+	}
+	if after, _ := c.Words.Get("wonderful"); after.Count[1] != seen+1 {
+		t.Errorf("Learning with a cloned pruned model should count words in its vocabulary\n\texpected %v\n\treturned %v\n", seen+1, after.Count[1])
+	}
+}
+
+func TestPruneVocabularyShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainEnglishModelFS(models, fixtureFS, IMDBClassDirs, &TrainOptions{
+		Vocabulary:    []string{"wonderful", "terrible", "boring", "awful", "happy", "unseen"},
+		MaxVocabulary: 3,
+	})
+	if err != nil {
+		t.Fatalf("Training with a fixed vocabulary should not return an error!\n\t%v\n", err)
+	}
+
+	// every allowed word is in two documents,
+	// so the tie is broken alphabetically
+	expected := []string{"awful", "boring", "happy"}
+	if !reflect.DeepEqual(models[English].Vocabulary, expected) {
+		t.Errorf("Vocabulary should be the 3 most frequent allowed words\n\texpected %v\n\treturned %v\n", expected, models[English].Vocabulary)
+	}
+}
+
+func TestPruneVocabularyShouldPass3(t *testing.T) {
+	t.Parallel()
+
+	// the tokenizer lowercases words, so the
+	// vocabulary is matched regardless of case
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{
+		Vocabulary: []string{"Great", "AWFUL", "boring"},
+	})
+	if err != nil {
+		t.Fatalf("Training with a fixed vocabulary should not return an error!\n\t%v\n", err)
+	}
+
+	expected := []string{"awful", "boring", "great"}
+	if !reflect.DeepEqual(models[English].Vocabulary, expected) {
+		t.Errorf("Vocabulary should be matched regardless of case\n\texpected %v\n\treturned %v\n", expected, models[English].Vocabulary)
+	}
+}
+
+func TestReadVocabularyShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	vocab, err := ReadVocabulary("datasets/imdb.vocab")
+	if err != nil {
+		t.Fatalf("Reading the IMDB vocabulary should not return an error!\n\t%v\n", err)
+	}
+
+	if len(vocab) < 80000 || vocab[0] != "the" {
+		t.Errorf("IMDB vocabulary should have ~89k words starting with < the >\n\treturned %v words starting with < %v >\n", len(vocab), vocab[0])
+	}
+}
@@ -0,0 +1,564 @@
+package sentiment
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+// LabelingFunction is a rule which votes
+// for the class of unlabeled text, or
+// abstains if it doesn't apply. Rules can
+// be noisy and disagree with each other;
+// a WeakSupervisor works out how much to
+// trust each of them.
+type LabelingFunction interface {
+	// Label returns the class the text is
+	// voted for, and false if the function
+	// abstains
+	Label(text string) (class uint8, ok bool)
+}
+
+// LabelingFunc is an ordinary function
+// used as a LabelingFunction
+type LabelingFunc func(text string) (uint8, bool)
+
+// Label calls f(text)
+func (f LabelingFunc) Label(text string) (uint8, bool) {
+	return f(text)
+}
+
+// KeywordLabeler returns a labeling function
+// voting for the class whenever the text uses
+// any of the keywords (or phrases), ignoring
+// case and punctuation, and abstaining
+// otherwise
+func KeywordLabeler(class uint8, keywords ...string) LabelingFunction {
+	phrases := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if words := normalizedWords(keyword); len(words) > 0 {
+			phrases = append(phrases, " "+strings.Join(words, " ")+" ")
+		}
+	}
+
+	return LabelingFunc(func(text string) (uint8, bool) {
+		padded := " " + strings.Join(normalizedWords(text), " ") + " "
+		for _, phrase := range phrases {
+			if strings.Contains(padded, phrase) {
+				return class, true
+			}
+		}
+
+		return 0, false
+	})
+}
+
+// RegexpLabeler returns a labeling function
+// voting for the class whenever the regular
+// expression matches the text, and abstaining
+// otherwise. Use (?i) to ignore case.
+func RegexpLabeler(class uint8, expr string) (LabelingFunction, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return LabelingFunc(func(text string) (uint8, bool) {
+		return class, re.MatchString(text)
+	}), nil
+}
+
+// ModelLabeler returns a labeling function
+// voting for the class the model predicts
+// whenever the model gives it at least the
+// confidence (from 0 to 1), and abstaining
+// otherwise. It's safe to use while the
+// model is in use elsewhere.
+func ModelLabeler(model *Model, confidence float64) LabelingFunction {
+	return LabelingFunc(func(text string) (uint8, bool) {
+		model.mu.RLock()
+		class, probs := model.probabilities(text)
+		model.mu.RUnlock()
+
+		return class, probs[class] >= confidence
+	})
+}
+
+// Combiner is how a WeakSupervisor resolves
+// the votes of its labeling functions into
+// class probabilities
+type Combiner string
+
+// Constants hold the combiners. The zero
+// value is LabelModel.
+const (
+	// LabelModel estimates how likely each
+	// labeling function is to vote for (or
+	// abstain on) documents of each class from
+	// how it agrees with the others (with
+	// Dawid-Skene expectation maximization,
+	// starting from the majority vote) and
+	// weighs its votes by that, so a few
+	// reliable rules outvote many noisy ones
+	// and a rule that fires on everything
+	// counts for little
+	LabelModel Combiner = "label_model"
+
+	// MajorityVote counts every vote the same
+	MajorityVote Combiner = "majority_vote"
+)
+
+// WeakSupervisor labels unlabeled text by
+// combining the votes of labeling functions,
+// to bootstrap models without hand labels
+type WeakSupervisor struct {
+	// Classes is the number of classes the
+	// functions vote for. It defaults to 2.
+	Classes int
+
+	// Combiner resolves the functions' votes
+	// into probabilities. It defaults to
+	// LabelModel.
+	Combiner Combiner
+
+	names     []string
+	functions []LabelingFunction
+}
+
+// Register adds a named labeling function
+// to the supervisor
+func (s *WeakSupervisor) Register(name string, fn LabelingFunction) error {
+	if fn == nil {
+		return fmt.Errorf("ERROR: labeling function < %v > is nil", name)
+	}
+	for _, registered := range s.names {
+		if registered == name {
+			return fmt.Errorf("ERROR: labeling function < %v > is already registered", name)
+		}
+	}
+
+	s.names = append(s.names, name)
+	s.functions = append(s.functions, fn)
+	return nil
+}
+
+// WeakLabels holds the probabilistic labels
+// a WeakSupervisor gave a corpus
+type WeakLabels struct {
+	// Documents is the number of documents
+	// labeled, and Covered the number any
+	// labeling function voted on
+	Documents int `json:"documents"`
+	Covered   int `json:"covered"`
+
+	// Failed holds the documents that
+	// couldn't be read, which were skipped
+	Failed []*DocumentError `json:"failed,omitempty"`
+
+	// Functions describes how each labeling
+	// function voted, in the order they were
+	// registered
+	Functions []*LabelingFunctionStats `json:"functions"`
+
+	// Priors is the estimated share of each
+	// class among the covered documents
+	Priors []float64 `json:"priors"`
+
+	// Labels holds the probability of each
+	// class for every document, in walk
+	// order, or nil for documents every
+	// function abstained on
+	Labels [][]float64 `json:"-"`
+
+	corpus Corpus
+}
+
+// LabelingFunctionStats describes how a
+// labeling function voted on a corpus
+type LabelingFunctionStats struct {
+	Name string `json:"name"`
+
+	// Coverage is the share of documents
+	// the function voted on
+	Coverage float64 `json:"coverage"`
+
+	// Overlap and Conflict are the shares
+	// of documents the function voted on
+	// along with another function, and
+	// against another function
+	Overlap  float64 `json:"overlap"`
+	Conflict float64 `json:"conflict"`
+
+	// Accuracy is the estimated share of
+	// the function's votes which are right
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Label runs every labeling function over
+// each document in the corpus (ignoring its
+// labels) and combines their votes into the
+// probability of each class. Train on the
+// result through WeakLabels.Corpus.
+//
+// Corpora are walked twice (once here and
+// again by WeakLabels.Corpus) so they have
+// to walk their documents in the same order
+// every time, which every corpus in this
+// package does.
+func (s *WeakSupervisor) Label(corpus Corpus) (*WeakLabels, error) {
+	classes := s.Classes
+	if classes == 0 {
+		classes = 2
+	}
+	if classes < 2 || classes > math.MaxUint8 {
+		return nil, fmt.Errorf("ERROR: can't label with %v classes", classes)
+	}
+	switch s.Combiner {
+	case "", LabelModel, MajorityVote:
+	default:
+		return nil, fmt.Errorf("ERROR: unknown combiner < %v >", s.Combiner)
+	}
+	if len(s.functions) == 0 {
+		return nil, fmt.Errorf("ERROR: no labeling functions are registered")
+	}
+
+	labels := &WeakLabels{
+		corpus: corpus,
+	}
+
+	// the vote of every function on every
+	// document, or -1 for abstaining
+	nf := len(s.functions)
+	var votes []int16
+	err := corpus.Walk(func(doc Document, err error) error {
+		if err != nil {
+			e, ok := err.(*DocumentError)
+			if !ok {
+				e = &DocumentError{Path: doc.Path, Err: err}
+			}
+			labels.Failed = append(labels.Failed, e)
+			return nil
+		}
+
+		for i, fn := range s.functions {
+			class, ok := fn.Label(doc.Text)
+			if !ok {
+				votes = append(votes, -1)
+				continue
+			}
+			if int(class) >= classes {
+				return fmt.Errorf("ERROR: labeling function < %v > voted for class %v of %v", s.names[i], class, classes)
+			}
+			votes = append(votes, int16(class))
+		}
+
+		labels.Documents++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error labeling corpus!\n\t%w\n", err)
+	}
+
+	labels.Priors = make([]float64, classes)
+	labels.Labels = make([][]float64, labels.Documents)
+	for i := range labels.Priors {
+		labels.Priors[i] = 1 / float64(classes)
+	}
+
+	// start from the majority vote, then
+	// alternate between estimating how each
+	// function votes on each class and
+	// relabeling with the estimates
+	combineVotes(labels, votes, nf, classes, nil)
+	if s.Combiner != MajorityVote {
+		for iter := 0; iter < 100; iter++ {
+			confusion := estimateConfusion(labels.Labels, votes, nf, classes)
+			if combineVotes(labels, votes, nf, classes, confusion) < 1e-6 {
+				break
+			}
+		}
+	}
+
+	// describe the functions
+	estimated := estimateAccuracy(labels.Labels, votes, nf)
+	for f, name := range s.names {
+		stats := &LabelingFunctionStats{
+			Name:     name,
+			Accuracy: estimated[f],
+		}
+
+		var voted, overlap, conflict int
+		for d := 0; d < labels.Documents; d++ {
+			row := votes[d*nf : (d+1)*nf]
+			if row[f] < 0 {
+				continue
+			}
+			voted++
+
+			overlaps, conflicts := false, false
+			for g, v := range row {
+				if g == f || v < 0 {
+					continue
+				}
+				overlaps = true
+				if v != row[f] {
+					conflicts = true
+				}
+			}
+			if overlaps {
+				overlap++
+			}
+			if conflicts {
+				conflict++
+			}
+		}
+
+		if labels.Documents > 0 {
+			stats.Coverage = float64(voted) / float64(labels.Documents)
+			stats.Overlap = float64(overlap) / float64(labels.Documents)
+			stats.Conflict = float64(conflict) / float64(labels.Documents)
+		}
+		labels.Functions = append(labels.Functions, stats)
+	}
+
+	return labels, nil
+}
+
+// Corpus returns the labeled corpus with each
+// document's most likely class as its label,
+// ready to be trained on like any other
+// corpus, e.g. with TrainModel. Documents
+// every function abstained on, whose most
+// likely classes are tied, or whose most
+// likely class has a probability below the
+// confidence (from 0 to 1) are skipped.
+func (l *WeakLabels) Corpus(confidence float64) Corpus {
+	return &weakCorpus{
+		labels:     l,
+		confidence: confidence,
+	}
+}
+
+// String returns a summary of the labeling
+// functions' votes
+func (l *WeakLabels) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "documents\t%v\n", l.Documents)
+	fmt.Fprintf(w, "unreadable\t%v\n", len(l.Failed))
+	fmt.Fprintf(w, "covered\t%v\n", l.Covered)
+	fmt.Fprintf(w, "priors\t%.3f\n", l.Priors)
+	fmt.Fprintf(w, "\nfunction\tcoverage\toverlap\tconflict\taccuracy\n")
+	for _, f := range l.Functions {
+		fmt.Fprintf(w, "%v\t%.3f\t%.3f\t%.3f\t%.3f\n", f.Name, f.Coverage, f.Overlap, f.Conflict, f.Accuracy)
+	}
+	w.Flush()
+
+	return buf.String()
+}
+
+// combineVotes sets the labels and priors from
+// the votes, returning the largest change in
+// any label. The votes are weighed by how
+// likely each function is to vote the way it
+// did (or abstain) on each class, by
+//
+//	confusion[function][class][vote + 1]
+//
+// or all counted the same if confusion is nil.
+func combineVotes(labels *WeakLabels, votes []int16, nf, classes int, confusion [][][]float64) float64 {
+	counts := make([]float64, classes)
+	labels.Covered = 0
+
+	var change float64
+	for d := range labels.Labels {
+		row := votes[d*nf : (d+1)*nf]
+
+		voted := false
+		for _, v := range row {
+			if v >= 0 {
+				voted = true
+			}
+		}
+		if !voted {
+			labels.Labels[d] = nil
+			continue
+		}
+		labels.Covered++
+
+		sums := make([]float64, classes)
+		var probs []float64
+		if confusion == nil {
+			var total float64
+			for _, v := range row {
+				if v >= 0 {
+					sums[v]++
+					total++
+				}
+			}
+			for c := range sums {
+				sums[c] /= total
+			}
+			probs = sums
+		} else {
+			for c := range sums {
+				sums[c] = math.Log(labels.Priors[c])
+				for f, v := range row {
+					sums[c] += math.Log(confusion[f][c][v+1])
+				}
+			}
+			_, probs = normalize(sums)
+		}
+
+		for c, p := range probs {
+			if old := labels.Labels[d]; old != nil {
+				change = math.Max(change, math.Abs(p-old[c]))
+			} else {
+				change = 1
+			}
+			counts[c] += p
+		}
+		labels.Labels[d] = probs
+	}
+
+	// smoothed, so no class is ever
+	// ruled out entirely
+	for c := range counts {
+		labels.Priors[c] = (counts[c] + 1) / (float64(labels.Covered) + float64(classes))
+	}
+
+	return change
+}
+
+// estimateConfusion returns the smoothed
+// probability of each function voting for
+// each class (or abstaining, at index 0) on
+// documents of each class under the labels
+func estimateConfusion(labels [][]float64, votes []int16, nf, classes int) [][][]float64 {
+	confusion := make([][][]float64, nf)
+	for f := range confusion {
+		confusion[f] = make([][]float64, classes)
+		for c := range confusion[f] {
+			confusion[f][c] = make([]float64, classes+1)
+		}
+	}
+
+	totals := make([]float64, classes)
+	for d, probs := range labels {
+		if probs == nil {
+			continue
+		}
+
+		for c, p := range probs {
+			totals[c] += p
+		}
+		for f, v := range votes[d*nf : (d+1)*nf] {
+			for c, p := range probs {
+				confusion[f][c][v+1] += p
+			}
+		}
+	}
+
+	for f := range confusion {
+		for c, row := range confusion[f] {
+			for v := range row {
+				row[v] = (row[v] + 0.5) / (totals[c] + 0.5*float64(classes+1))
+			}
+		}
+	}
+
+	return confusion
+}
+
+// estimateAccuracy returns the expected share
+// of each function's votes that are right
+// under the labels, smoothed towards 1/2
+func estimateAccuracy(labels [][]float64, votes []int16, nf int) []float64 {
+	right := make([]float64, nf)
+	total := make([]float64, nf)
+	for d, probs := range labels {
+		if probs == nil {
+			continue
+		}
+
+		for f, v := range votes[d*nf : (d+1)*nf] {
+			if v < 0 {
+				continue
+			}
+			right[f] += probs[v]
+			total[f]++
+		}
+	}
+
+	accuracy := make([]float64, nf)
+	for f := range accuracy {
+		accuracy[f] = (right[f] + 1) / (total[f] + 2)
+	}
+
+	return accuracy
+}
+
+// weakCorpus walks a corpus, labeling each
+// document with its weak label
+type weakCorpus struct {
+	labels     *WeakLabels
+	confidence float64
+}
+
+func (c *weakCorpus) Walk(fn WalkFunc) error {
+	return c.labels.corpus.Walk(c.label(fn))
+}
+
+// WalkConcurrent reads the underlying corpus
+// concurrently if it can be
+func (c *weakCorpus) WalkConcurrent(workers int, fn WalkFunc) error {
+	if concurrent, ok := c.labels.corpus.(ConcurrentCorpus); ok {
+		return concurrent.WalkConcurrent(workers, c.label(fn))
+	}
+
+	return c.Walk(fn)
+}
+
+// label wraps fn so it's called with each
+// document's weak label, skipping the ones
+// that aren't confident enough. Documents
+// that couldn't be read aren't counted, same
+// as when labeling.
+func (c *weakCorpus) label(fn WalkFunc) WalkFunc {
+	var index int
+	return func(doc Document, err error) error {
+		if err != nil {
+			return fn(doc, err)
+		}
+
+		i := index
+		index++
+		if i >= len(c.labels.Labels) {
+			return fmt.Errorf("ERROR: corpus has more documents than were labeled")
+		}
+
+		probs := c.labels.Labels[i]
+		if probs == nil {
+			return nil
+		}
+
+		class, tied := 0, false
+		for j, p := range probs {
+			switch {
+			case p > probs[class]:
+				class, tied = j, false
+			case j != class && p == probs[class]:
+				tied = true
+			}
+		}
+		if tied || probs[class] < c.confidence {
+			return nil
+		}
+
+		doc.Class = uint8(class)
+		return fn(doc, nil)
+	}
+}
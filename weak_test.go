@@ -0,0 +1,172 @@
+package sentiment
+
+import (
+	"testing"
+)
+
+var weaklyLabeled = Documents{
+	{Path: "a", Text: "What a wonderful film"},
+	{Path: "b", Text: "A boring, awful waste of time"},
+	{Path: "c", Text: "Delightful from start to finish"},
+	{Path: "d", Text: "The film opens on a Tuesday"},
+	{Path: "e", Text: "An awful script, boring too"},
+	{Path: "f", Text: "Great acting and a great story"},
+	{Path: "g", Text: "What a waste"},
+	{Path: "h", Text: "Boring and such a waste of money"},
+	{Path: "i", Text: "Awful. Just awful, what a waste"},
+}
+
+// newWeakSupervisor returns a supervisor with
+// good keyword rules and one rule that always
+// votes positive
+func newWeakSupervisor(t *testing.T, combiner Combiner) *WeakSupervisor {
+	waste, err := RegexpLabeler(0, `(?i)\bwaste\b`)
+	if err != nil {
+		t.Fatalf("Compiling a regexp labeler should not return an error!\n\t%v\n", err)
+	}
+
+	s := &WeakSupervisor{Combiner: combiner}
+	for name, fn := range map[string]LabelingFunction{
+		"positive words": KeywordLabeler(1, "wonderful", "delightful", "GREAT ACTING"),
+		"negative words": KeywordLabeler(0, "boring", "awful"),
+		"waste":          waste,
+		"optimist": LabelingFunc(func(text string) (uint8, bool) {
+			return 1, len(text) > 20
+		}),
+	} {
+		err = s.Register(name, fn)
+		if err != nil {
+			t.Fatalf("Registering labeling function < %v > should not return an error!\n\t%v\n", name, err)
+		}
+	}
+
+	return s
+}
+
+func TestWeakSupervisionShouldPass1(t *testing.T) {
+	t.Parallel()
+
+	for _, combiner := range []Combiner{LabelModel, MajorityVote} {
+		labels, err := newWeakSupervisor(t, combiner).Label(weaklyLabeled)
+		if err != nil {
+			t.Fatalf("Labeling with %v should not return an error!\n\t%v\n", combiner, err)
+		}
+
+		if labels.Documents != 9 || labels.Covered != 9 || len(labels.Functions) != 4 {
+			t.Errorf("Labeling with %v should cover 9 documents with 4 functions\n\treturned %v\n", combiner, labels)
+		}
+
+		docs, err := ReadAll(labels.Corpus(0.5))
+		if err != nil {
+			t.Fatalf("Reading weakly labeled corpus should not return an error!\n\t%v\n", err)
+		}
+
+		classes := make(map[string]uint8)
+		for _, doc := range docs {
+			classes[doc.Path] = doc.Class
+		}
+		for path, class := range map[string]uint8{"a": 1, "b": 0, "c": 1, "d": 1, "f": 1, "g": 0, "h": 0, "i": 0} {
+			if c, ok := classes[path]; !ok || c != class {
+				t.Errorf("%v should label document < %v > as %v\n\treturned %v (%v)\n", combiner, path, class, c, ok)
+			}
+		}
+
+		if combiner == MajorityVote {
+			// one vote each way
+			if _, ok := classes["e"]; ok {
+				t.Errorf("Majority vote should skip tied documents\n\treturned %v\n", classes)
+			}
+			continue
+		}
+		if classes["e"] != 0 {
+			t.Errorf("Label model should side with the more reliable rule\n\treturned %v\n", classes["e"])
+		}
+
+		var optimist, negative *LabelingFunctionStats
+		for _, f := range labels.Functions {
+			switch f.Name {
+			case "optimist":
+				optimist = f
+			case "negative words":
+				negative = f
+			}
+		}
+		if optimist.Accuracy >= negative.Accuracy || optimist.Conflict == 0 {
+			t.Errorf("Label model should trust a rule that's often outvoted less\n\treturned %+v and %+v\n", optimist, negative)
+		}
+	}
+}
+
+func TestWeakSupervisionShouldPass2(t *testing.T) {
+	t.Parallel()
+
+	models := make(Models)
+	_, err := TrainModel(models, English, skewed, &TrainOptions{Priors: []float64{0.5, 0.5}})
+	if err != nil {
+		t.Fatalf("Training should not return an error!\n\t%v\n", err)
+	}
+
+	s := &WeakSupervisor{}
+	err = s.Register("model", ModelLabeler(models[English], 0.6))
+	if err != nil {
+		t.Fatalf("Registering a model labeler should not return an error!\n\t%v\n", err)
+	}
+	err = s.Register("negative words", KeywordLabeler(0, "waste"))
+	if err != nil {
+		t.Fatalf("Registering a keyword labeler should not return an error!\n\t%v\n", err)
+	}
+
+	labels, err := s.Label(weaklyLabeled)
+	if err != nil {
+		t.Fatalf("Labeling should not return an error!\n\t%v\n", err)
+	}
+	if labels.Labels[3] != nil {
+		t.Errorf("Model labeler should abstain on documents it's unsure of\n\treturned %v\n", labels.Labels[3])
+	}
+
+	// train a new domain's model on
+	// the weak labels
+	weak := make(Models)
+	result, err := TrainModel(weak, Language("reviews"), labels.Corpus(0.6), nil)
+	if err != nil {
+		t.Fatalf("Training on weak labels should not return an error!\n\t%v\n", err)
+	}
+	if result.Classes[0] == 0 || result.Classes[1] == 0 {
+		t.Errorf("Weak labels should have documents of both classes\n\treturned %v\n", result.Classes)
+	}
+	if class := weak[Language("reviews")].Predict("such a waste"); class != 0 {
+		t.Errorf("Model trained on weak labels should predict < such a waste > as negative\n\treturned %v\n", class)
+	}
+}
+
+func TestWeakSupervisionShouldFail1(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (&WeakSupervisor{}).Label(weaklyLabeled); err == nil {
+		t.Errorf("Labeling without labeling functions should return an error\n")
+	}
+
+	s := newWeakSupervisor(t, "")
+	s.Classes = 256
+	if _, err := s.Label(weaklyLabeled); err == nil {
+		t.Errorf("Labeling with 256 classes should return an error\n")
+	}
+
+	s = newWeakSupervisor(t, "")
+	if err := s.Register("waste", KeywordLabeler(0, "waste")); err == nil {
+		t.Errorf("Registering a labeling function twice should return an error\n")
+	}
+	if _, err := RegexpLabeler(0, "("); err == nil {
+		t.Errorf("Compiling a malformed regexp labeler should return an error\n")
+	}
+
+	s.Register("neutral", KeywordLabeler(2, "opens"))
+	if _, err := s.Label(weaklyLabeled); err == nil {
+		t.Errorf("Voting for a class the supervisor doesn't have should return an error\n")
+	}
+
+	s = newWeakSupervisor(t, "snorkel")
+	if _, err := s.Label(weaklyLabeled); err == nil {
+		t.Errorf("Labeling with an unknown combiner should return an error\n")
+	}
+}